@@ -1,3 +1,7 @@
 module goroutines_01
 
 go 1.22.1
+
+require goroutines_05 v0.0.0-00010101000000-000000000000
+
+replace goroutines_05 => ../05
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"time"
+	"goroutines_05/group"
 )
 
 func hello() {
@@ -10,8 +11,13 @@ func hello() {
 }
 
 func main() {
-	go hello()
-	time.Sleep(1 * time.Second)
+	// the group waits for hello to finish, so main no longer has to sleep and hope it was long enough
+	g, _ := group.New(context.Background())
+	g.Go(func(ctx context.Context) error {
+		hello()
+		return nil
+	})
+	g.Wait()
 	fmt.Println("main function")
 }
 
@@ -1,3 +1,13 @@
 module goroutines_02
 
 go 1.22.1
+
+require (
+	goroutines_04 v0.0.0-00010101000000-000000000000
+	goroutines_05 v0.0.0-00010101000000-000000000000
+)
+
+replace (
+	goroutines_04 => ../04
+	goroutines_05 => ../05
+)
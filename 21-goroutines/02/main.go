@@ -1,28 +1,44 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"goroutines_04/clock"
+	"goroutines_05/group"
 	"time"
 )
 
+// numbers and alphabets sleep on clk.
+// With a clock.Fake the output is the same interleaving without the 2 second wait.
+var clk clock.Clock = clock.Real()
+
 func numbers() {
 	for i := 1; i <= 5; i++ {
-		time.Sleep(250 * time.Millisecond)
+		clk.Sleep(250 * time.Millisecond)
 		fmt.Printf("%d ", i)
 	}
 }
 
 func alphabets() {
 	for i := 'a'; i <= 'e'; i++ {
-		time.Sleep(400 * time.Millisecond)
+		clk.Sleep(400 * time.Millisecond)
 		fmt.Printf("%c ", i)
 	}
 }
 
 func main() {
-	go numbers()
-	go alphabets()
-	time.Sleep(3000 * time.Millisecond)
+	// main used to sleep for 3 seconds to outlast both Goroutines.
+	// Now it waits on the group, which returns as soon as the slower one, alphabets, is done after 2 seconds.
+	g, _ := group.New(context.Background())
+	g.Go(func(ctx context.Context) error {
+		numbers()
+		return nil
+	})
+	g.Go(func(ctx context.Context) error {
+		alphabets()
+		return nil
+	})
+	g.Wait()
 	fmt.Println("main terminated")
 }
 
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"goroutines_03/timeline"
+	"goroutines_04/clock"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// numbers and alphabets are the two Goroutines from 21-goroutines/02,
+// recording what they print instead of writing it straight to stdout.
+// They sleep and timestamp their events on clk, a clock.Fake makes the recording the same every run.
+func numbers(clk clock.Clock, rec *timeline.Recorder) {
+	for i := 1; i <= 5; i++ {
+		clk.Sleep(250 * time.Millisecond)
+		rec.Record("numbers", strconv.Itoa(i))
+	}
+}
+
+func alphabets(clk clock.Clock, rec *timeline.Recorder) {
+	for i := 'a'; i <= 'e'; i++ {
+		clk.Sleep(400 * time.Millisecond)
+		rec.Record("alphabets", string(i))
+	}
+}
+
+// example is the main function of 21-goroutines/02, starting its Goroutines with spawn
+func example(clk clock.Clock, rec *timeline.Recorder, spawn func(func())) {
+	rec.Record("main", "start")
+	spawn(func() { numbers(clk, rec) })
+	spawn(func() { alphabets(clk, rec) })
+	clk.Sleep(3000 * time.Millisecond)
+	rec.Record("main", "main terminated")
+}
+
+// run runs the example and returns what it recorded.
+// With fake set it runs on a clock.Fake, which records the same timeline every time without waiting 3 seconds.
+func run(fake bool) timeline.Recording {
+	if !fake {
+		clk := clock.Real()
+		rec := newRecorder(clk)
+		example(clk, rec, func(fn func()) { go fn() })
+		return rec.Recording()
+	}
+
+	// every Goroutine has to be started with f.Go so the fake clock knows when they are all blocked
+	f := clock.NewFake(time.Time{})
+	rec := newRecorder(f)
+	f.Go(func() { example(f, rec, f.Go) })
+	f.Wait()
+	return rec.Recording()
+}
+
+func newRecorder(clk clock.Clock) *timeline.Recorder {
+	rec := timeline.NewRecorder(clk)
+	rec.Lane("main")
+	rec.Lane("numbers")
+	rec.Lane("alphabets")
+	return rec
+}
+
+func main() {
+	record := flag.String("record", "", "run the example and save the recording to this file instead of rendering it")
+	render := flag.String("render", "", "render a recording saved with -record instead of running the example")
+	format := flag.String("format", "ascii", "output format: ascii or svg")
+	step := flag.Duration("step", 50*time.Millisecond, "the time each column stands for in the ascii format")
+	fake := flag.Bool("fake", false, "run the example on a fake clock, instantly and with the same timings every run")
+	flag.Parse()
+
+	if err := cli(*record, *render, *format, *step, *fake, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func cli(record, render, format string, step time.Duration, fake bool, out io.Writer) error {
+	// check the flags before spending 3 seconds recording
+	draw, err := renderer(format, step)
+	if err != nil {
+		return err
+	}
+
+	var rec timeline.Recording
+	if render != "" {
+		f, err := os.Open(render)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if rec, err = timeline.ReadJSON(f); err != nil {
+			return fmt.Errorf("reading %s: %w", render, err)
+		}
+	} else {
+		rec = run(fake)
+	}
+
+	if record != "" {
+		f, err := os.Create(record)
+		if err != nil {
+			return err
+		}
+		if err := rec.WriteJSON(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	return draw(out, rec)
+}
+
+// renderer returns the function which draws a recording in format
+func renderer(format string, step time.Duration) (func(io.Writer, timeline.Recording) error, error) {
+	switch format {
+	case "ascii":
+		if step <= 0 {
+			return nil, fmt.Errorf("step must be positive, got %s", step)
+		}
+		return func(w io.Writer, rec timeline.Recording) error { return timeline.RenderASCII(w, rec, step) }, nil
+	case "svg":
+		return timeline.RenderSVG, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, want ascii or svg", format)
+	}
+}
+
+// go run .
+//           |0s        500ms     1s        1.5s      2s        2.5s      3s
+// main      |start-------------------------------------------------------main terminated
+// numbers   |     1----2----3----4----5
+// alphabets |        a-------b-------c-------d-------e
+//
+// order: start 1 a 2 3 b 4 c 5 d e main terminated
+
+// go run . -fake
+// draws the same timeline, instantly
+
+// go run . -record run.json
+// go run . -render run.json -format svg > run.svg
@@ -0,0 +1,107 @@
+package timeline
+
+import (
+	"encoding/json"
+	"goroutines_04/clock"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// An Event is something that happened on one lane of the timeline,
+// for example the numbers Goroutine printing 3.
+type Event struct {
+	Lane  string        `json:"lane"`
+	Label string        `json:"label"`
+	At    time.Duration `json:"at_ns"`
+}
+
+// A Recording is a finished run: the lanes in the order they first appeared and every event, sorted by time.
+type Recording struct {
+	Lanes  []string `json:"lanes"`
+	Events []Event  `json:"events"`
+}
+
+// End returns the time of the last event.
+func (r Recording) End() time.Duration {
+	if len(r.Events) == 0 {
+		return 0
+	}
+	return r.Events[len(r.Events)-1].At
+}
+
+// A Recorder collects events from any number of Goroutines.
+// Times are measured on its clock from the moment the Recorder was created.
+type Recorder struct {
+	clock clock.Clock
+	start time.Time
+
+	mu     sync.Mutex
+	lanes  []string
+	events []Event
+}
+
+// NewRecorder creates a Recorder which timestamps events with c, starting now.
+func NewRecorder(c clock.Clock) *Recorder {
+	return &Recorder{clock: c, start: c.Now()}
+}
+
+// Record adds an event with the given label to lane.
+// It is safe to call from several Goroutines at the same time.
+func (r *Recorder) Record(lane, label string) {
+	at := r.clock.Since(r.start)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.hasLane(lane) {
+		r.lanes = append(r.lanes, lane)
+	}
+	r.events = append(r.events, Event{Lane: lane, Label: label, At: at})
+}
+
+// Lane declares a lane before anything is recorded on it, to fix the order lanes are drawn in.
+func (r *Recorder) Lane(lane string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.hasLane(lane) {
+		r.lanes = append(r.lanes, lane)
+	}
+}
+
+func (r *Recorder) hasLane(lane string) bool {
+	for _, l := range r.lanes {
+		if l == lane {
+			return true
+		}
+	}
+	return false
+}
+
+// Recording returns a copy of what has been recorded so far.
+func (r *Recorder) Recording() Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := Recording{
+		Lanes:  append([]string(nil), r.lanes...),
+		Events: append([]Event(nil), r.events...),
+	}
+	sort.SliceStable(rec.Events, func(i, j int) bool { return rec.Events[i].At < rec.Events[j].At })
+	return rec
+}
+
+// WriteJSON saves the recording so it can be rendered later.
+func (r Recording) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ReadJSON loads a recording saved with WriteJSON.
+func ReadJSON(rd io.Reader) (Recording, error) {
+	var rec Recording
+	if err := json.NewDecoder(rd).Decode(&rec); err != nil {
+		return Recording{}, err
+	}
+	sort.SliceStable(rec.Events, func(i, j int) bool { return rec.Events[i].At < rec.Events[j].At })
+	return rec, nil
+}
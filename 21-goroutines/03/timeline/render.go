@@ -0,0 +1,121 @@
+package timeline
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// RenderASCII draws the recording as one row per lane, one column per step of time.
+// A lane is drawn with '-' between its first and last event, and each event's label is written at the column it happened in.
+// The order the labels happened in is printed underneath, which for 21-goroutines/02 is "1 a 2 3 b 4 c 5 d e main terminated".
+func RenderASCII(w io.Writer, rec Recording, step time.Duration) error {
+	if step <= 0 {
+		return fmt.Errorf("timeline: step must be positive, got %s", step)
+	}
+	width := 0
+	for _, l := range rec.Lanes {
+		width = max(width, len(l))
+	}
+	cols := int(rec.End()/step) + 1
+
+	var sb strings.Builder
+	// the time axis, labelled every 10 columns
+	axis := []byte(strings.Repeat(" ", cols+20))
+	for c := 0; c < cols; c += 10 {
+		copy(axis[c:], fmt.Sprint(time.Duration(c)*step))
+	}
+	fmt.Fprintf(&sb, "%-*s |%s\n", width, "", strings.TrimRight(string(axis), " "))
+
+	for _, lane := range rec.Lanes {
+		first, last := -1, -1
+		for _, e := range rec.Events {
+			if e.Lane != lane {
+				continue
+			}
+			c := int(e.At / step)
+			if first < 0 {
+				first = c
+			}
+			last = c
+		}
+		row := []byte(strings.Repeat(" ", cols))
+		for c := first; c >= 0 && c <= last; c++ {
+			row[c] = '-'
+		}
+		for _, e := range rec.Events {
+			if e.Lane != lane {
+				continue
+			}
+			c := int(e.At / step)
+			// a label that runs past the end of the row makes the row longer
+			if need := c + len(e.Label); need > len(row) {
+				row = append(row, strings.Repeat(" ", need-len(row))...)
+			}
+			copy(row[c:], e.Label)
+		}
+		fmt.Fprintf(&sb, "%-*s |%s\n", width, lane, strings.TrimRight(string(row), " "))
+	}
+
+	labels := make([]string, len(rec.Events))
+	for i, e := range rec.Events {
+		labels[i] = e.Label
+	}
+	fmt.Fprintf(&sb, "\norder: %s\n", strings.Join(labels, " "))
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// the sizes, in pixels, used by RenderSVG
+const (
+	svgLaneHeight = 40
+	svgLabelWidth = 100
+	svgWidth      = 900
+	svgPadding    = 20
+)
+
+// RenderSVG draws the recording as a swimlane diagram, one horizontal lane per Goroutine with a marker for every event.
+func RenderSVG(w io.Writer, rec Recording) error {
+	end := rec.End()
+	if end == 0 {
+		end = time.Millisecond
+	}
+	plot := float64(svgWidth - svgLabelWidth - 2*svgPadding)
+	x := func(d time.Duration) float64 {
+		return svgLabelWidth + svgPadding + plot*float64(d)/float64(end)
+	}
+	height := svgPadding*2 + svgLaneHeight*(len(rec.Lanes)+1)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="12">`+"\n", svgWidth, height)
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+
+	lanes := make(map[string]int, len(rec.Lanes))
+	for i, lane := range rec.Lanes {
+		lanes[lane] = i
+		y := svgPadding + svgLaneHeight*i + svgLaneHeight/2
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" dominant-baseline="middle">%s</text>`+"\n", svgPadding, y, html.EscapeString(lane))
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#ccc"/>`+"\n", x(0), y, x(end), y)
+	}
+
+	for _, e := range rec.Events {
+		y := svgPadding + svgLaneHeight*lanes[e.Lane] + svgLaneHeight/2
+		fmt.Fprintf(&sb, `<circle cx="%.1f" cy="%d" r="4" fill="steelblue"><title>%s %s at %s</title></circle>`+"\n",
+			x(e.At), y, html.EscapeString(e.Lane), html.EscapeString(e.Label), e.At.Round(time.Millisecond))
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n", x(e.At), y-8, html.EscapeString(e.Label))
+	}
+
+	// the time axis along the bottom
+	axisY := svgPadding + svgLaneHeight*len(rec.Lanes) + svgLaneHeight/2
+	fmt.Fprintf(&sb, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="black"/>`+"\n", x(0), axisY, x(end), axisY)
+	for i := 0; i <= 10; i++ {
+		d := end * time.Duration(i) / 10
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n", x(d), axisY+16, d.Round(time.Millisecond))
+	}
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,142 @@
+package timeline
+
+import (
+	"bytes"
+	"flag"
+	"goroutines_04/clock"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// go test -update rewrites the golden files after a deliberate change to the output
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with the file testdata/name, or writes it there with -update
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s, run go test -update and diff it:\n%s", path, got)
+	}
+}
+
+// record plays 21-goroutines/02 on a fake clock, one step at a time,
+// the way numbers wakes every 250ms and alphabets every 400ms
+func record() Recording {
+	f := clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	rec := NewRecorder(f)
+	rec.Lane("main")
+	rec.Lane("numbers")
+	rec.Lane("alphabets")
+
+	rec.Record("main", "start")
+	for at := 50 * time.Millisecond; at <= 3000*time.Millisecond; at += 50 * time.Millisecond {
+		f.Advance(50 * time.Millisecond)
+		if at%(250*time.Millisecond) == 0 && at <= 1250*time.Millisecond {
+			rec.Record("numbers", strconv.Itoa(int(at/(250*time.Millisecond))))
+		}
+		if at%(400*time.Millisecond) == 0 && at <= 2000*time.Millisecond {
+			rec.Record("alphabets", string(rune('a'+at/(400*time.Millisecond)-1)))
+		}
+	}
+	rec.Record("main", "main terminated")
+	return rec.Recording()
+}
+
+func TestRecorder(t *testing.T) {
+	rec := record()
+	if want := []string{"main", "numbers", "alphabets"}; !reflect.DeepEqual(rec.Lanes, want) {
+		t.Errorf("lanes %v, want %v", rec.Lanes, want)
+	}
+	if len(rec.Events) != 12 {
+		t.Fatalf("recorded %d events, want 12: %+v", len(rec.Events), rec.Events)
+	}
+	if e := rec.Events[1]; e != (Event{Lane: "numbers", Label: "1", At: 250 * time.Millisecond}) {
+		t.Errorf("the second event is %+v, want numbers printing 1 at 250ms", e)
+	}
+	if rec.End() != 3*time.Second {
+		t.Errorf("the recording ends at %s, want 3s", rec.End())
+	}
+
+	// an event on a lane which wasn't declared adds it at the end
+	r := NewRecorder(clock.NewFake(time.Time{}))
+	r.Record("b", "x")
+	r.Lane("a")
+	r.Lane("b")
+	if lanes := r.Recording().Lanes; !reflect.DeepEqual(lanes, []string{"b", "a"}) {
+		t.Errorf("lanes %v, want [b a]", lanes)
+	}
+}
+
+func TestRenderASCII(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		step time.Duration
+	}{
+		{"50ms", 50 * time.Millisecond},
+		// labels written into the same column overwrite each other
+		{"500ms", 500 * time.Millisecond},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := RenderASCII(&out, record(), tt.step); err != nil {
+				t.Fatal(err)
+			}
+			golden(t, "ascii-"+tt.name+".golden", out.Bytes())
+		})
+	}
+
+	if err := RenderASCII(&bytes.Buffer{}, record(), 0); err == nil {
+		t.Error("rendered with a step of 0")
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	var out bytes.Buffer
+	if err := RenderSVG(&out, record()); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "svg.golden", out.Bytes())
+
+	// an empty recording still has a time axis
+	out.Reset()
+	if err := RenderSVG(&out, Recording{}); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "svg-empty.golden", out.Bytes())
+}
+
+func TestJSON(t *testing.T) {
+	rec := record()
+	var out bytes.Buffer
+	if err := rec.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "recording.json", out.Bytes())
+
+	got, err := ReadJSON(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rec) {
+		t.Errorf("read back %+v, want %+v", got, rec)
+	}
+
+	if _, err := ReadJSON(bytes.NewBufferString(`{"lanes": [`)); err == nil {
+		t.Error("read a truncated recording")
+	}
+}
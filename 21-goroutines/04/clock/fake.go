@@ -0,0 +1,369 @@
+package clock
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A Fake is a Clock whose time only moves when it is told to.
+// Sleeping on a Fake blocks until the clock is advanced past the wake up time,
+// which lets a program that sleeps for seconds run in microseconds and always in the same order.
+type Fake struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	now  time.Time
+	// every timer, ticker and sleep which has not fired yet
+	pending []*fakeTimer
+	// used to break ties between timers with the same deadline, first created fires first
+	seq uint64
+
+	// Goroutines started with Go which have not returned yet
+	live int
+	// the ids of those of them which are already running
+	ids map[int64]bool
+}
+
+// NewFake returns a Fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start, ids: make(map[int64]bool)}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+type fakeTimer struct {
+	f        *Fake
+	deadline time.Time
+	seq      uint64
+	c        chan time.Time
+	// period is set for tickers, which are rescheduled every time they fire
+	period time.Duration
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Sleep blocks until the clock has been advanced by d.
+func (f *Fake) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	f.mu.Lock()
+	t := f.schedule(d, 0)
+	f.cond.Broadcast()
+	f.mu.Unlock()
+	<-t.c
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := f.schedule(d, 0)
+	f.cond.Broadcast()
+	return t
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := f.schedule(d, d)
+	f.cond.Broadcast()
+	return fakeTicker{t}
+}
+
+// schedule adds a timer firing d from now. The caller holds f.mu.
+func (f *Fake) schedule(d, period time.Duration) *fakeTimer {
+	f.seq++
+	t := &fakeTimer{f: f, deadline: f.now.Add(d), seq: f.seq, c: make(chan time.Time, 1), period: period}
+	if d <= 0 {
+		// like the time package, a timer for zero or less fires straight away
+		t.c <- f.now
+		return t
+	}
+	f.pending = append(f.pending, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer whose deadline is reached on the way, in deadline order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	target := f.now.Add(d)
+	for {
+		t := f.next()
+		if t == nil || t.deadline.After(target) {
+			break
+		}
+		f.fire(t)
+	}
+	f.now = target
+}
+
+// AdvanceNext moves the clock forward to the earliest pending deadline and fires that one timer.
+// It returns how far the clock moved, and false if nothing was waiting.
+func (f *Fake) AdvanceNext() (time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.advanceNext()
+}
+
+func (f *Fake) advanceNext() (time.Duration, bool) {
+	t := f.next()
+	if t == nil {
+		return 0, false
+	}
+	moved := t.deadline.Sub(f.now)
+	f.fire(t)
+	return moved, true
+}
+
+// next returns the pending timer which fires first. The caller holds f.mu.
+func (f *Fake) next() *fakeTimer {
+	var first *fakeTimer
+	for _, t := range f.pending {
+		if first == nil || t.deadline.Before(first.deadline) || (t.deadline.Equal(first.deadline) && t.seq < first.seq) {
+			first = t
+		}
+	}
+	return first
+}
+
+// fire sets the clock to t's deadline and delivers the tick. The caller holds f.mu.
+func (f *Fake) fire(t *fakeTimer) {
+	f.now = t.deadline
+	select {
+	case t.c <- f.now:
+	default:
+		// like time.Ticker, a tick nobody has read yet is dropped
+	}
+	if t.period > 0 {
+		f.seq++
+		t.seq = f.seq
+		t.deadline = t.deadline.Add(t.period)
+	} else {
+		f.remove(t)
+	}
+	f.cond.Broadcast()
+}
+
+// remove drops t from the pending timers, reporting whether it was there. The caller holds f.mu.
+func (f *Fake) remove(t *fakeTimer) bool {
+	for i, p := range f.pending {
+		if p == t {
+			f.pending = append(f.pending[:i], f.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Pending returns the number of sleeps, timers and tickers waiting for the clock to move.
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.pending)
+}
+
+// BlockUntil waits until at least n sleeps, timers or tickers are waiting for the clock to move.
+// Use it before Advance to be sure the Goroutines under test have reached their sleep.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.pending) < n {
+		f.cond.Wait()
+	}
+}
+
+// Go runs fn in a new Goroutine which Wait keeps track of.
+func (f *Fake) Go(fn func()) {
+	f.mu.Lock()
+	f.live++
+	f.mu.Unlock()
+	go func() {
+		id := goroutineID()
+		f.mu.Lock()
+		f.ids[id] = true
+		f.mu.Unlock()
+		defer func() {
+			f.mu.Lock()
+			f.live--
+			delete(f.ids, id)
+			f.cond.Broadcast()
+			f.mu.Unlock()
+		}()
+		fn()
+	}()
+}
+
+// Wait runs the Goroutines started with Go to completion.
+// Whenever every one of them is blocked, whether in Sleep, on a timer or ticker channel, or on a channel between them,
+// it advances the clock to the next deadline, so they wake up one at a time in deadline order.
+// It returns once they have all returned.
+//
+// Every Goroutine taking part has to be started with Go: Wait can't know that a blocked Goroutine
+// is about to be woken by one it doesn't track, and would move the clock on too early.
+// If they are all blocked and there is no deadline left to advance to, they can never wake up, and Wait panics.
+func (f *Fake) Wait() {
+	for {
+		f.mu.Lock()
+		if f.live == 0 {
+			f.mu.Unlock()
+			return
+		}
+		var ids map[int64]bool
+		// a Goroutine which hasn't stored its id yet is still starting up
+		if len(f.ids) == f.live {
+			ids = make(map[int64]bool, len(f.ids))
+			for id := range f.ids {
+				ids[id] = true
+			}
+		}
+		f.mu.Unlock()
+
+		if ids == nil || !allBlocked(ids) {
+			// let them run, then look again
+			time.Sleep(100 * time.Microsecond)
+			continue
+		}
+		f.mu.Lock()
+		_, ok := f.advanceNext()
+		f.mu.Unlock()
+		if !ok {
+			panic("clock: every Goroutine started with Go is blocked and no timer is pending")
+		}
+	}
+}
+
+// blockedStates are the states in a stack dump of a Goroutine which waits for another Goroutine or the clock,
+// as opposed to running or waiting for the operating system
+var blockedStates = [][]byte{
+	[]byte("chan receive"), []byte("chan send"), []byte("select"),
+	[]byte("sync.Cond.Wait"), []byte("sync.Mutex.Lock"), []byte("sync.RWMutex.Lock"), []byte("sync.RWMutex.RLock"),
+	[]byte("sync.WaitGroup.Wait"), []byte("semacquire"),
+}
+
+// allBlocked reports whether every Goroutine in ids is blocked, according to a dump of every Goroutine's stack.
+// The dump is taken with the world stopped, so the states in it are all from the same moment.
+func allBlocked(ids map[int64]bool) bool {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	blocked := 0
+	// every Goroutine starts with a line like "goroutine 18 [chan receive, 2 minutes]:"
+	for _, g := range bytes.Split(buf, []byte("\n\n")) {
+		header, _, _ := bytes.Cut(g, []byte("\n"))
+		header, ok := bytes.CutPrefix(header, []byte("goroutine "))
+		if !ok {
+			continue
+		}
+		id, state, ok := bytes.Cut(header, []byte(" ["))
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(string(id), 10, 64)
+		if err != nil || !ids[n] {
+			continue
+		}
+		if !isBlocked(state) {
+			return false
+		}
+		blocked++
+	}
+	return blocked == len(ids)
+}
+
+func isBlocked(state []byte) bool {
+	for _, s := range blockedStates {
+		if bytes.HasPrefix(state, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// goroutineID reads the id of the calling goroutine from the first line of its stack trace,
+// "goroutine 18 [running]:". Go deliberately doesn't expose it any other way.
+func goroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseInt(string(buf), 10, 64)
+	return id
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	return t.f.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	f := t.f
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	active := f.remove(t)
+	f.seq++
+	t.seq = f.seq
+	t.deadline = f.now.Add(d)
+	if d <= 0 {
+		select {
+		case t.c <- f.now:
+		default:
+		}
+		return active
+	}
+	f.pending = append(f.pending, t)
+	f.cond.Broadcast()
+	return active
+}
+
+// fakeTicker is a fakeTimer with a period, wrapped to give Stop and Reset the signatures of time.Ticker
+type fakeTicker struct{ t *fakeTimer }
+
+func (k fakeTicker) C() <-chan time.Time {
+	return k.t.c
+}
+
+func (k fakeTicker) Stop() {
+	k.t.Stop()
+}
+
+func (k fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	k.t.f.mu.Lock()
+	k.t.period = d
+	k.t.f.mu.Unlock()
+	k.t.Reset(d)
+}
@@ -0,0 +1,313 @@
+package clock
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fired reports whether c has a tick waiting, and its time.
+// A Fake delivers ticks while it advances, so there is nothing to wait for.
+func fired(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-c:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestSleep(t *testing.T) {
+	f := NewFake(start)
+	done := make(chan struct{})
+	go func() {
+		f.Sleep(time.Second)
+		close(done)
+	}()
+	f.BlockUntil(1)
+
+	f.Advance(999 * time.Millisecond)
+	if f.Pending() != 1 {
+		t.Errorf("the sleep ended %s early", time.Millisecond)
+	}
+	f.Advance(time.Millisecond)
+	<-done
+	if got := f.Since(start); got != time.Second {
+		t.Errorf("the clock moved %s, want 1s", got)
+	}
+
+	// like time.Sleep, sleeping for no time returns straight away
+	f.Sleep(0)
+	f.Sleep(-time.Second)
+}
+
+func TestAfter(t *testing.T) {
+	f := NewFake(start)
+	c := f.After(2 * time.Second)
+	f.Advance(time.Second)
+	if _, ok := fired(c); ok {
+		t.Error("After fired after 1s, want 2s")
+	}
+	f.Advance(time.Hour)
+	if got, ok := fired(c); !ok || !got.Equal(start.Add(2*time.Second)) {
+		t.Errorf("After delivered %s, %t, want the time it was due", got, ok)
+	}
+	if !f.Now().Equal(start.Add(time.Hour + time.Second)) {
+		t.Errorf("the clock is at %s, want the end of the advance", f.Now())
+	}
+}
+
+func TestTimer(t *testing.T) {
+	f := NewFake(start)
+	tm := f.NewTimer(time.Second)
+	if !tm.Stop() {
+		t.Error("stopping a pending timer returned false")
+	}
+	if tm.Stop() {
+		t.Error("stopping a stopped timer returned true")
+	}
+	f.Advance(2 * time.Second)
+	if _, ok := fired(tm.C()); ok {
+		t.Error("a stopped timer fired")
+	}
+
+	// a reset counts from now
+	if tm.Reset(time.Second) {
+		t.Error("resetting a stopped timer returned true")
+	}
+	if !tm.Reset(3 * time.Second) {
+		t.Error("resetting a pending timer returned false")
+	}
+	f.Advance(2 * time.Second)
+	if _, ok := fired(tm.C()); ok {
+		t.Error("the timer fired at its first deadline, before the reset one")
+	}
+	f.Advance(time.Second)
+	if got, ok := fired(tm.C()); !ok || !got.Equal(start.Add(5*time.Second)) {
+		t.Errorf("the timer delivered %s, %t, want 3s after the reset", got, ok)
+	}
+
+	// like the time package, a timer for no time fires straight away
+	if _, ok := fired(f.NewTimer(0).C()); !ok {
+		t.Error("a timer for 0s didn't fire")
+	}
+	tm.Reset(-time.Second)
+	if _, ok := fired(tm.C()); !ok {
+		t.Error("a timer reset to -1s didn't fire")
+	}
+	if f.Pending() != 0 {
+		t.Errorf("%d timers pending, want 0", f.Pending())
+	}
+}
+
+func TestTicker(t *testing.T) {
+	f := NewFake(start)
+	tk := f.NewTicker(time.Second)
+
+	// like time.Ticker, the ticks at 2s and 3s are dropped because nobody read the one at 1s
+	f.Advance(3500 * time.Millisecond)
+	if got, ok := fired(tk.C()); !ok || !got.Equal(start.Add(time.Second)) {
+		t.Errorf("first tick %s, %t, want the one at 1s", got, ok)
+	}
+	if _, ok := fired(tk.C()); ok {
+		t.Error("a second tick was queued")
+	}
+	f.Advance(500 * time.Millisecond)
+	if got, ok := fired(tk.C()); !ok || !got.Equal(start.Add(4*time.Second)) {
+		t.Errorf("tick %s, %t, want the one at 4s", got, ok)
+	}
+
+	// after a reset it ticks every 2s from now
+	tk.Reset(2 * time.Second)
+	for _, want := range []time.Duration{6 * time.Second, 8 * time.Second} {
+		f.Advance(2 * time.Second)
+		if got, ok := fired(tk.C()); !ok || !got.Equal(start.Add(want)) {
+			t.Errorf("tick %s, %t, want the one at %s", got, ok, want)
+		}
+	}
+
+	tk.Stop()
+	f.Advance(time.Minute)
+	if _, ok := fired(tk.C()); ok {
+		t.Error("a stopped ticker ticked")
+	}
+
+	for name, fn := range map[string]func(){
+		"NewTicker(0)": func() { f.NewTicker(0) },
+		"Reset(0)":     func() { f.NewTicker(time.Second).Reset(0) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s didn't panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestAdvanceNext(t *testing.T) {
+	f := NewFake(start)
+	timers := map[string]Timer{
+		"a": f.NewTimer(2 * time.Second),
+		"b": f.NewTimer(time.Second),
+	}
+	// c is due at the same time as b, and fires after it because it was created later
+	timers["c"] = f.NewTimer(time.Second)
+
+	for _, want := range []struct {
+		moved time.Duration
+		timer string
+	}{
+		{time.Second, "b"},
+		{0, "c"},
+		{time.Second, "a"},
+	} {
+		moved, ok := f.AdvanceNext()
+		if !ok || moved != want.moved {
+			t.Errorf("AdvanceNext moved %s, %t, want %s", moved, ok, want.moved)
+		}
+		for name, tm := range timers {
+			if _, ok := fired(tm.C()); ok != (name == want.timer) {
+				t.Errorf("after moving to %s, timer %s fired %t", f.Since(start), name, ok)
+			}
+		}
+	}
+	if moved, ok := f.AdvanceNext(); ok || moved != 0 {
+		t.Errorf("AdvanceNext with nothing pending moved %s, %t, want 0s, false", moved, ok)
+	}
+}
+
+func TestBlockUntil(t *testing.T) {
+	f := NewFake(start)
+	var wg sync.WaitGroup
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.Sleep(time.Duration(i) * time.Second)
+		}()
+	}
+	f.BlockUntil(3)
+	if f.Pending() != 3 {
+		t.Errorf("%d pending, want 3", f.Pending())
+	}
+	f.Advance(3 * time.Second)
+	wg.Wait()
+	// it returns straight away when there are enough already
+	f.BlockUntil(0)
+}
+
+func TestWait(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		// run starts the Goroutines, each logging what it did
+		run  func(f *Fake, log func(string))
+		want []string
+	}{
+		{
+			name: "sleep",
+			run: func(f *Fake, log func(string)) {
+				f.Go(func() { f.Sleep(2 * time.Second); log("b") })
+				f.Go(func() { f.Sleep(time.Second); log("a") })
+			},
+			want: []string{"1s a", "2s b"},
+		},
+		{
+			name: "after and timers",
+			run: func(f *Fake, log func(string)) {
+				f.Go(func() { <-f.After(3 * time.Second); log("after") })
+				f.Go(func() {
+					tm := f.NewTimer(time.Second)
+					<-tm.C()
+					log("timer")
+					tm.Reset(time.Second)
+					<-tm.C()
+					log("reset")
+				})
+			},
+			want: []string{"1s timer", "2s reset", "3s after"},
+		},
+		{
+			name: "ticker",
+			run: func(f *Fake, log func(string)) {
+				f.Go(func() {
+					tk := f.NewTicker(time.Second)
+					defer tk.Stop()
+					for i := 0; i < 3; i++ {
+						<-tk.C()
+						log("tick")
+					}
+				})
+			},
+			want: []string{"1s tick", "2s tick", "3s tick"},
+		},
+		{
+			// the receiver is blocked on a channel, not the clock, until the sender wakes up
+			name: "channel between Goroutines",
+			run: func(f *Fake, log func(string)) {
+				ch := make(chan string)
+				f.Go(func() {
+					for v := range ch {
+						log(v)
+					}
+				})
+				f.Go(func() {
+					f.Sleep(time.Second)
+					ch <- "one"
+					f.Sleep(time.Second)
+					ch <- "two"
+					close(ch)
+				})
+			},
+			want: []string{"1s one", "2s two"},
+		},
+		{
+			name: "select with a timeout",
+			run: func(f *Fake, log func(string)) {
+				ch := make(chan int)
+				f.Go(func() {
+					select {
+					case <-ch:
+						log("received")
+					case <-f.After(5 * time.Second):
+						log("timed out")
+					}
+				})
+			},
+			want: []string{"5s timed out"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFake(start)
+			var mu sync.Mutex
+			var got []string
+			tt.run(f, func(s string) {
+				mu.Lock()
+				defer mu.Unlock()
+				got = append(got, f.Since(start).String()+" "+s)
+			})
+			f.Wait()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitPanicsWhenNothingCanWake(t *testing.T) {
+	f := NewFake(start)
+	never := make(chan struct{})
+	defer close(never)
+	f.Go(func() { <-never })
+	defer func() {
+		if recover() == nil {
+			t.Error("Wait returned with a Goroutine blocked for ever")
+		}
+	}()
+	f.Wait()
+}
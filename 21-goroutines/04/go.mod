@@ -0,0 +1,3 @@
+module goroutines_04
+
+go 1.22.1
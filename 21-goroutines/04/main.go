@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"goroutines_04/clock"
+	"time"
+)
+
+// numbers and alphabets from 21-goroutines/02, sleeping on a Clock instead of calling time.Sleep
+func numbers(c clock.Clock) {
+	for i := 1; i <= 5; i++ {
+		c.Sleep(250 * time.Millisecond)
+		fmt.Printf("%d ", i)
+	}
+}
+
+func alphabets(c clock.Clock) {
+	for i := 'a'; i <= 'e'; i++ {
+		c.Sleep(400 * time.Millisecond)
+		fmt.Printf("%c ", i)
+	}
+}
+
+func main() {
+	started := time.Now()
+
+	// The fake clock starts at the zero time and only moves when every Goroutine started with Go is blocked.
+	// The program "sleeps" for 3 seconds but finishes straight away, and prints the same thing every run.
+	c := clock.NewFake(time.Time{})
+	c.Go(func() { numbers(c) })
+	c.Go(func() { alphabets(c) })
+	c.Go(func() {
+		c.Sleep(3000 * time.Millisecond)
+		fmt.Println("main terminated")
+	})
+	c.Wait()
+
+	fmt.Println("fake time passed:", c.Since(time.Time{}))
+	fmt.Println("real time passed less than a second:", time.Since(started) < time.Second)
+}
+
+// 1 a 2 3 b 4 c 5 d e main terminated
+// fake time passed: 3s
+// real time passed less than a second: true
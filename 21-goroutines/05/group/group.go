@@ -0,0 +1,117 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// A Group runs child Goroutines and waits for all of them to finish.
+// It replaces the time.Sleep in main that the first Goroutine examples use to keep the program alive:
+// instead of guessing how long the children need, main waits for exactly as long as they take.
+//
+// The first child to return an error, or to panic, cancels the context the other children were given
+// and becomes the error returned by Wait.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	wg sync.WaitGroup
+	// sem holds one token per running child when a limit is set
+	sem chan struct{}
+
+	errOnce sync.Once
+	err     error
+}
+
+// New returns an empty Group and the context its children run with.
+// The context is cancelled when a child fails or when Wait returns.
+func New(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	g := &Group{ctx: ctx, cancel: cancel}
+	return g, ctx
+}
+
+// SetLimit limits the Group to n children running at the same time; Go blocks until there is room.
+// A negative n removes the limit. A limit of 0 would make every Go block for ever, so SetLimit panics instead.
+// It must not be called while children are running.
+func (g *Group) SetLimit(n int) {
+	if n == 0 {
+		panic("group: SetLimit(0) leaves no room for any child, use a negative limit for no limit")
+	}
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic(fmt.Errorf("group: SetLimit called while %d children are running", len(g.sem)))
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go spawns fn in a new Goroutine.
+// fn should return when ctx is done, which happens as soon as one of its siblings fails.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	// the child gives its token back to the semaphore it took it from, even if the limit changes
+	sem := g.sem
+	if sem != nil {
+		sem <- struct{}{}
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.done(sem)
+		if err := g.call(fn); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// call runs fn, turning a panic into a *PanicError
+func (g *Group) call(fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return fn(g.ctx)
+}
+
+func (g *Group) done(sem chan struct{}) {
+	if sem != nil {
+		<-sem
+	}
+	g.wg.Done()
+}
+
+// fail records the first error and cancels the remaining children
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel(err)
+	})
+}
+
+// Wait blocks until every child has returned, then returns the first error, if any.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(context.Canceled)
+	return g.err
+}
+
+// A PanicError is returned by Wait when a child panicked instead of returning.
+type PanicError struct {
+	Value any
+	// Stack is the stack trace of the Goroutine at the point it panicked
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap returns the panic value if it was an error, such as a runtime error from an out of range index.
+func (p *PanicError) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
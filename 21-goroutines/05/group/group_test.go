@@ -0,0 +1,241 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWait(t *testing.T) {
+	// a group without children has nothing to wait for
+	g, ctx := New(context.Background())
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait with no children = %v, want nil", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("the context is still live after Wait")
+	}
+
+	g, ctx = New(context.Background())
+	var done atomic.Int32
+	release := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		g.Go(func(ctx context.Context) error {
+			<-release
+			done.Add(1)
+			return nil
+		})
+	}
+	waited := make(chan error)
+	go func() { waited <- g.Wait() }()
+	select {
+	case <-waited:
+		t.Fatal("Wait returned while the children were still running")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if ctx.Err() != nil {
+		t.Error("the context was cancelled while the children were running")
+	}
+	close(release)
+	if err := <-waited; err != nil {
+		t.Errorf("Wait = %v, want nil", err)
+	}
+	if n := done.Load(); n != 10 {
+		t.Errorf("Wait returned after %d children were done, want 10", n)
+	}
+}
+
+func TestFirstErrorCancelsSiblings(t *testing.T) {
+	first := errors.New("first")
+	g, ctx := New(context.Background())
+
+	// the siblings run until the context is cancelled, and report what cancelled it
+	causes := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		g.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			causes <- context.Cause(ctx)
+			return ctx.Err()
+		})
+	}
+	g.Go(func(ctx context.Context) error { return first })
+	// a later error doesn't replace the first one
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return errors.New("second")
+	})
+
+	if err := g.Wait(); err != first {
+		t.Errorf("Wait = %v, want %v", err, first)
+	}
+	for i := 0; i < 3; i++ {
+		if cause := <-causes; cause != first {
+			t.Errorf("a sibling was cancelled by %v, want %v", cause, first)
+		}
+	}
+	if cause := context.Cause(ctx); cause != first {
+		t.Errorf("the group's context was cancelled by %v, want %v", cause, first)
+	}
+}
+
+func TestParentCancel(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	g, _ := New(parent)
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	cancel()
+	if err := g.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait = %v, want context.Canceled", err)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		fn    func(ctx context.Context) error
+		value string
+		// unwrapped is whether the panic value is an error PanicError unwraps to
+		unwrapped bool
+	}{
+		{
+			name:  "value",
+			fn:    func(ctx context.Context) error { panic("boom") },
+			value: "panic: boom",
+		},
+		{
+			name: "runtime error",
+			fn: func(ctx context.Context) error {
+				var s []int
+				s[3] = 1
+				return nil
+			},
+			value:     "panic: runtime error: index out of range [3] with length 0",
+			unwrapped: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _ := New(context.Background())
+			g.Go(tt.fn)
+			err := g.Wait()
+			var perr *PanicError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Wait = %v, want a *PanicError", err)
+			}
+			if err.Error() != tt.value {
+				t.Errorf("error %q, want %q", err, tt.value)
+			}
+			var rerr runtime.Error
+			if errors.As(err, &rerr) != tt.unwrapped {
+				t.Errorf("unwraps to a runtime.Error %t, want %t", !tt.unwrapped, tt.unwrapped)
+			}
+			// the stack is the one the child panicked on
+			if !strings.Contains(string(perr.Stack), "TestPanic") {
+				t.Errorf("stack doesn't show the panicking function:\n%s", perr.Stack)
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	for _, limit := range []int{1, 3} {
+		g, _ := New(context.Background())
+		g.SetLimit(limit)
+		var mu sync.Mutex
+		running, most := 0, 0
+		for i := 0; i < 20; i++ {
+			g.Go(func(ctx context.Context) error {
+				mu.Lock()
+				running++
+				most = max(most, running)
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+		}
+		g.Wait()
+		if most != limit {
+			t.Errorf("with a limit of %d, %d children ran at once", limit, most)
+		}
+	}
+}
+
+func TestGoBlocksAtTheLimit(t *testing.T) {
+	g, _ := New(context.Background())
+	g.SetLimit(1)
+	release := make(chan struct{})
+	g.Go(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	started := make(chan struct{})
+	go func() {
+		g.Go(func(ctx context.Context) error { return nil })
+		close(started)
+	}()
+	select {
+	case <-started:
+		t.Fatal("Go started a second child past the limit of 1")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	<-started
+	g.Wait()
+}
+
+func TestSetLimit(t *testing.T) {
+	// a negative limit removes it again, so this doesn't block
+	g, _ := New(context.Background())
+	g.SetLimit(1)
+	g.SetLimit(-1)
+	release := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		g.Go(func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+	}
+	// children started without a limit don't take tokens from one set later
+	g.SetLimit(2)
+	g.Go(func(ctx context.Context) error { return nil })
+	close(release)
+	g.Wait()
+
+	for _, tt := range []struct {
+		name string
+		fn   func(g *Group)
+	}{
+		{"a limit of 0", func(g *Group) { g.SetLimit(0) }},
+		{"while children are running", func(g *Group) {
+			g.SetLimit(1)
+			g.Go(func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			})
+			g.SetLimit(2)
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// cancelling the parent ends the child left running
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			g, _ := New(ctx)
+			defer func() {
+				if recover() == nil {
+					t.Error("SetLimit didn't panic")
+				}
+			}()
+			tt.fn(g)
+		})
+	}
+}
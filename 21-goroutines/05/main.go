@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goroutines_04/clock"
+	"goroutines_05/group"
+	"time"
+	"unicode"
+)
+
+// hello, numbers and alphabets from 21-goroutines/01 and 02, sleeping on clk.
+// As children of a group they return an error instead of printing it, and stop sleeping once their siblings have failed.
+var clk clock.Clock = clock.Real()
+
+// hello greets the i-th of names
+func hello(names []string, i int) error {
+	fmt.Println("Hello", names[i], "goroutine")
+	return nil
+}
+
+func numbers(ctx context.Context) error {
+	for i := 1; i <= 5; i++ {
+		if err := sleep(ctx, 250*time.Millisecond); err != nil {
+			return err
+		}
+		fmt.Printf("%d ", i)
+	}
+	return nil
+}
+
+// alphabets prints the letters of s, failing at the first character which isn't a letter
+func alphabets(ctx context.Context, s string) error {
+	for _, r := range s {
+		if err := sleep(ctx, 400*time.Millisecond); err != nil {
+			return err
+		}
+		if !unicode.IsLetter(r) {
+			return fmt.Errorf("alphabets: %q is not a letter", r)
+		}
+		fmt.Printf("%c ", r)
+	}
+	return nil
+}
+
+// sleep waits for d, or returns early with the reason ctx was cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-clk.After(d):
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+func main() {
+	// 1. main waits for its children instead of sleeping
+	g, _ := group.New(context.Background())
+	g.Go(func(ctx context.Context) error { return hello([]string{"world"}, 0) })
+	g.Go(numbers)
+	g.Go(func(ctx context.Context) error { return alphabets(ctx, "abcde") })
+	fmt.Println("\nerror:", g.Wait())
+
+	// 2. the first error cancels the siblings and is returned by Wait:
+	// alphabets fails at 800ms, and numbers stops before printing 4 at 1s
+	g, _ = group.New(context.Background())
+	g.Go(numbers)
+	g.Go(func(ctx context.Context) error { return alphabets(ctx, "a3cde") })
+	fmt.Println("\nerror:", g.Wait())
+
+	// 3. with a limit of 1, alphabets only starts once numbers is done
+	g, _ = group.New(context.Background())
+	g.SetLimit(1)
+	g.Go(numbers)
+	g.Go(func(ctx context.Context) error { return alphabets(ctx, "abcde") })
+	fmt.Println("\nerror:", g.Wait())
+
+	// 4. a panic in a child becomes an error instead of crashing the program
+	g, _ = group.New(context.Background())
+	g.Go(func(ctx context.Context) error { return hello(nil, 3) })
+	err := g.Wait()
+	var perr *group.PanicError
+	fmt.Println("recovered:", errors.As(err, &perr), err)
+}
+
+// Hello world goroutine
+// 1 a 2 3 b 4 c 5 d e
+// error: <nil>
+// 1 a 2 3
+// error: alphabets: '3' is not a letter
+// 1 2 3 4 5 a b c d e
+// error: <nil>
+// recovered: true panic: runtime error: index out of range [3] with length 0
@@ -1,3 +1,7 @@
 module channels_03
 
 go 1.22.1
+
+require goroutines_04 v0.0.0-00010101000000-000000000000
+
+replace goroutines_04 => ../../21-goroutines/04
@@ -2,14 +2,18 @@ package main
 
 import (
 	"fmt"
+	"goroutines_04/clock"
 	"time"
 )
 
+// hello takes its 4 second nap on clk
+var clk clock.Clock = clock.Real()
+
 func hello(done chan bool) {
 	// [4] print
 	fmt.Println("hello go routine is going to sleep")
 	// [5] wait 4 seconds
-	time.Sleep(4 * time.Second)
+	clk.Sleep(4 * time.Second)
 	// [6] print
 	fmt.Println("hello go routine awake and going to write to done")
 	// [7] send a boolean to the channel
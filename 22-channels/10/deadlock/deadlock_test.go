@@ -0,0 +1,172 @@
+package deadlock
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// go test -update rewrites the golden files after a deliberate change to the output
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with the file testdata/name, or writes it there with -update
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s, run go test -update and diff it:\n%s", path, got)
+	}
+}
+
+// dumps returns the stack dumps in testdata by name, without the .txt extension
+func dumps(t *testing.T) map[string][]byte {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no stack dumps in testdata: %v", err)
+	}
+	ds := make(map[string][]byte)
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ds[strings.TrimSuffix(filepath.Base(f), ".txt")] = b
+	}
+	return ds
+}
+
+func frame(f Frame) string {
+	return fmt.Sprintf("%s %s:%d", f.Func, f.File, f.Line)
+}
+
+func TestParse(t *testing.T) {
+	for name, dump := range dumps(t) {
+		t.Run(name, func(t *testing.T) {
+			var sb strings.Builder
+			for _, g := range Parse(dump) {
+				fmt.Fprintf(&sb, "goroutine %d [%s] wait %s blocked on a channel %t\n", g.ID, g.State, g.Wait, g.BlockedOnChannel())
+				for _, f := range g.Frames {
+					fmt.Fprintf(&sb, "    %s\n", frame(f))
+				}
+				fmt.Fprintf(&sb, "    created by %s\n", frame(g.CreatedBy))
+				fmt.Fprintf(&sb, "    at %s\n", frame(g.Location()))
+			}
+			golden(t, name+".parse.golden", []byte(sb.String()))
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for name, dump := range dumps(t) {
+		t.Run(name, func(t *testing.T) {
+			gs := Parse(dump)
+			// the monitor started at start and checks at 1s and 3.5s
+			m := NewMonitor(2*time.Second, time.Second, nil)
+			m.last = start
+			var out bytes.Buffer
+			for _, at := range []time.Duration{time.Second, 3500 * time.Millisecond} {
+				fmt.Fprintf(&out, "after %s\n", at)
+				m.check(gs, start.Add(at)).WriteTo(&out)
+			}
+			golden(t, name+".report.golden", out.Bytes())
+		})
+	}
+}
+
+func TestGoroutineMovingOnIsTimedAgain(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := Goroutine{ID: 7, State: "chan send", Frames: []Frame{{Func: "main.send", File: "main.go", Line: 13}}}
+	m := NewMonitor(2*time.Second, time.Second, nil)
+
+	// the first check has nothing to count from
+	if r := m.check([]Goroutine{g}, start); r.Groups[0].Goroutines[0].For != 0 {
+		t.Errorf("first check: blocked %s, want 0s", r.Groups[0].Goroutines[0].For)
+	}
+	r := m.check([]Goroutine{g}, start.Add(3*time.Second))
+	if b := r.Groups[0].Goroutines[0]; b.For != 3*time.Second || !b.Stuck || r.Stuck() != 1 {
+		t.Errorf("after 3s: %+v, want blocked 3s and stuck", b)
+	}
+
+	// it got past the send and is now waiting on another one, which it started after the last check
+	g.Frames[0].Line = 14
+	r = m.check([]Goroutine{g}, start.Add(4*time.Second))
+	if b := r.Groups[0].Goroutines[0]; b.For != time.Second || b.Stuck {
+		t.Errorf("after moving on: %+v, want blocked 1s and not stuck", b)
+	}
+
+	// the runtime's wait time wins when it is longer
+	g.Wait = 5 * time.Minute
+	r = m.check([]Goroutine{g}, start.Add(5*time.Second))
+	if b := r.Groups[0].Goroutines[0]; b.For != 5*time.Minute {
+		t.Errorf("with a runtime wait: %+v, want blocked 5m", b)
+	}
+
+	// goroutines which are no longer blocked are forgotten
+	m.check(nil, start.Add(6*time.Second))
+	if len(m.seen) != 0 {
+		t.Errorf("the monitor still remembers %v", m.seen)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	m := NewMonitor(time.Hour, time.Millisecond, &bytes.Buffer{})
+	// stopping a monitor which never started does nothing
+	m.Stop()
+
+	block := make(chan int)
+	go func() { block <- 1 }()
+	defer func() { <-block }()
+
+	var out syncBuffer
+	m = NewMonitor(time.Hour, time.Millisecond, &out)
+	m.Start()
+	m.Start()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "TestStartStop") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	m.Stop()
+	m.Stop()
+	if !strings.Contains(out.String(), "[chan send] channels_10/deadlock.TestStartStop.func") {
+		t.Errorf("the reports don't show the blocked send:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "(*Monitor).run") {
+		t.Errorf("the monitor reported itself:\n%s", out.String())
+	}
+}
+
+// syncBuffer is a bytes.Buffer the monitor can write to while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,246 @@
+package deadlock
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Blocked goroutine is one that was parked on a channel operation when the report was taken.
+type Blocked struct {
+	ID int
+	// For is how long the goroutine has been blocked at the same place.
+	// The monitor only looks every interval, so it counts from the last check which didn't see the goroutine blocked there,
+	// which can be up to one interval before it really blocked.
+	For time.Duration
+	// Stuck is set once For reaches the monitor's threshold
+	Stuck bool
+}
+
+// A Group collects every goroutine blocked on the same channel operation at the same line of code.
+type Group struct {
+	Op         string
+	Func       string
+	File       string
+	Line       int
+	Goroutines []Blocked
+}
+
+// A Report is the result of one check.
+// Groups are sorted by location and goroutines by id, so two reports can be diffed line by line.
+type Report struct {
+	Threshold time.Duration
+	Groups    []Group
+}
+
+// Stuck returns the number of goroutines blocked for at least the threshold.
+func (r Report) Stuck() int {
+	n := 0
+	for _, g := range r.Groups {
+		for _, b := range g.Goroutines {
+			if b.Stuck {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// WriteTo writes the report in its text form.
+// Only the base name of each file is printed so reports from different machines stay comparable,
+// and times are rounded to a tenth of a second.
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	total := 0
+	for _, g := range r.Groups {
+		total += len(g.Goroutines)
+	}
+	fmt.Fprintf(&sb, "blocked on channels: %d goroutines, %d stuck longer than %s\n", total, r.Stuck(), r.Threshold)
+	for _, g := range r.Groups {
+		fmt.Fprintf(&sb, "[%s] %s %s:%d (%d)\n", g.Op, g.Func, filepath.Base(g.File), g.Line, len(g.Goroutines))
+		for _, b := range g.Goroutines {
+			mark := ""
+			if b.Stuck {
+				mark = " STUCK"
+			}
+			fmt.Fprintf(&sb, "    goroutine %d blocked %s%s\n", b.ID, b.For.Round(100*time.Millisecond), mark)
+		}
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func (r Report) String() string {
+	var sb strings.Builder
+	r.WriteTo(&sb)
+	return sb.String()
+}
+
+// the goroutine and place it was blocked at when it was first seen
+type sighting struct {
+	op    string
+	where Frame
+	since time.Time
+}
+
+// A Monitor periodically dumps the goroutine stacks of the program it is embedded in
+// and writes a Report of the goroutines blocked on channel operations.
+type Monitor struct {
+	threshold time.Duration
+	interval  time.Duration
+	out       io.Writer
+
+	mu   sync.Mutex
+	seen map[int]sighting
+	// last is the time of the last check, or of Start, zero before either
+	last time.Time
+	// stop is nil while the monitor is not running
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewMonitor creates a Monitor which writes a report to out every interval
+// and flags goroutines which have been blocked for at least threshold.
+func NewMonitor(threshold, interval time.Duration, out io.Writer) *Monitor {
+	return &Monitor{
+		threshold: threshold,
+		interval:  interval,
+		out:       out,
+		seen:      make(map[int]sighting),
+	}
+}
+
+// Start runs the monitor in its own goroutine until Stop is called.
+// Goroutines already blocked when it starts count as blocked since then. Starting a running monitor does nothing.
+func (m *Monitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		return
+	}
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	if m.last.IsZero() {
+		m.last = time.Now()
+	}
+	go m.run(m.stop, m.done)
+}
+
+// Stop ends the monitor goroutine and waits for it to exit.
+// Stopping a monitor which is not running does nothing.
+func (m *Monitor) Stop() {
+	m.mu.Lock()
+	stop, done := m.stop, m.done
+	m.stop, m.done = nil, nil
+	m.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (m *Monitor) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r := m.Check()
+			r.WriteTo(m.out)
+		}
+	}
+}
+
+// Check takes a snapshot of all goroutines now and returns the report for it.
+func (m *Monitor) Check() Report {
+	return m.check(Snapshot(), time.Now())
+}
+
+func (m *Monitor) check(gs []Goroutine, now time.Time) Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	groups := make(map[string]*Group)
+	current := make(map[int]sighting)
+	for _, g := range gs {
+		if !g.BlockedOnChannel() || ownGoroutine(g) {
+			continue
+		}
+		where := g.Location()
+		s, ok := m.seen[g.ID]
+		if !ok || s.op != g.State || s.where != where {
+			// new goroutine, or it has moved on to a different operation since the last check,
+			// so it blocked some time after that check
+			since := m.last
+			if since.IsZero() {
+				since = now
+			}
+			s = sighting{op: g.State, where: where, since: since}
+		}
+		current[g.ID] = s
+
+		blockedFor := now.Sub(s.since)
+		// the runtime knows better than we do when the goroutine was blocked before the monitor started
+		if g.Wait > blockedFor {
+			blockedFor = g.Wait
+		}
+
+		key := fmt.Sprintf("%s|%s|%s|%d", g.State, where.Func, where.File, where.Line)
+		grp, ok := groups[key]
+		if !ok {
+			grp = &Group{Op: g.State, Func: where.Func, File: where.File, Line: where.Line}
+			groups[key] = grp
+		}
+		grp.Goroutines = append(grp.Goroutines, Blocked{
+			ID:    g.ID,
+			For:   blockedFor,
+			Stuck: blockedFor >= m.threshold,
+		})
+	}
+	// forget goroutines which are no longer blocked
+	m.seen = current
+	m.last = now
+
+	r := Report{Threshold: m.threshold}
+	for _, grp := range groups {
+		sort.Slice(grp.Goroutines, func(i, j int) bool { return grp.Goroutines[i].ID < grp.Goroutines[j].ID })
+		r.Groups = append(r.Groups, *grp)
+	}
+	sort.Slice(r.Groups, func(i, j int) bool {
+		a, b := r.Groups[i], r.Groups[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Op < b.Op
+	})
+	return r
+}
+
+// the import path of this package, e.g. "channels_10/deadlock"
+var pkgPath = func() string {
+	name := runtime.FuncForPC(reflect.ValueOf(Parse).Pointer()).Name()
+	return strings.TrimSuffix(name, ".Parse")
+}()
+
+// ownGoroutine reports whether g is the goroutine of a running monitor, which spends its life waiting in a select
+func ownGoroutine(g Goroutine) bool {
+	for _, f := range g.Frames {
+		if f.Func == pkgPath+".(*Monitor).run" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package deadlock
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Frame is one function call in a goroutine's stack trace.
+type Frame struct {
+	Func string
+	File string
+	Line int
+}
+
+// A Goroutine is one entry parsed from a runtime stack dump.
+type Goroutine struct {
+	ID int
+	// State is the wait reason the runtime prints in brackets, e.g. "chan send" or "select (no cases)"
+	State string
+	// Wait is how long the runtime says the goroutine has been blocked.
+	// The runtime only reports this in whole minutes, so it is zero for anything shorter.
+	Wait      time.Duration
+	Frames    []Frame
+	CreatedBy Frame
+}
+
+// the wait reasons the runtime uses for goroutines blocked on a channel operation
+var channelStates = map[string]bool{
+	"chan send":               true,
+	"chan receive":            true,
+	"chan send (nil chan)":    true,
+	"chan receive (nil chan)": true,
+	"select":                  true,
+	"select (no cases)":       true,
+}
+
+// BlockedOnChannel reports whether the goroutine is parked on a channel send, receive or select.
+func (g Goroutine) BlockedOnChannel() bool {
+	return channelStates[g.State]
+}
+
+// Location returns the first frame outside of the runtime,
+// which is the line of user code that performed the blocking channel operation.
+func (g Goroutine) Location() Frame {
+	for _, f := range g.Frames {
+		if !strings.HasPrefix(f.Func, "runtime.") {
+			return f
+		}
+	}
+	return Frame{}
+}
+
+// Snapshot returns every goroutine currently running in the program.
+func Snapshot() []Goroutine {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return Parse(buf[:n])
+		}
+		// the dump did not fit, so try again with a bigger buffer
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// goroutine 7 [chan receive, 3 minutes]:
+// goroutine 18 gp=0xc000102380 m=nil [select]:
+var headerRE = regexp.MustCompile(`^goroutine (\d+) (?:[^\[]*)\[([^\]]*)\]:$`)
+
+// Parse reads the output of runtime.Stack (or a "fatal error: all goroutines are asleep" crash)
+// and returns the goroutines it contains.
+func Parse(dump []byte) []Goroutine {
+	var gs []Goroutine
+	var g *Goroutine
+	// set when the previous line was a function name and the next line holds its file and line
+	var pending *Frame
+
+	sc := bufio.NewScanner(bytes.NewReader(dump))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := headerRE.FindStringSubmatch(line); m != nil {
+			id, _ := strconv.Atoi(m[1])
+			gs = append(gs, parseHeader(id, m[2]))
+			g = &gs[len(gs)-1]
+			pending = nil
+			continue
+		}
+		if g == nil || line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			if pending != nil {
+				pending.File, pending.Line = parseFileLine(line)
+				pending = nil
+			}
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "created by "); ok {
+			// "created by main.main in goroutine 1"
+			name, _, _ := strings.Cut(rest, " in goroutine ")
+			g.CreatedBy = Frame{Func: name}
+			pending = &g.CreatedBy
+			continue
+		}
+		g.Frames = append(g.Frames, Frame{Func: funcName(line)})
+		pending = &g.Frames[len(g.Frames)-1]
+	}
+	return gs
+}
+
+// parseHeader splits "chan receive, 3 minutes, locked to thread" into the state and the wait time
+func parseHeader(id int, bracket string) Goroutine {
+	parts := strings.Split(bracket, ", ")
+	g := Goroutine{ID: id, State: parts[0]}
+	for _, p := range parts[1:] {
+		if n, ok := strings.CutSuffix(p, " minutes"); ok {
+			if mins, err := strconv.Atoi(n); err == nil {
+				g.Wait = time.Duration(mins) * time.Minute
+			}
+		}
+	}
+	return g
+}
+
+// funcName strips the argument list from "main.write(0xc000016120)"
+func funcName(line string) string {
+	if i := strings.LastIndex(line, "("); i > 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// parseFileLine parses "\t/home/user/main.go:14 +0x25"
+func parseFileLine(line string) (string, int) {
+	line = strings.TrimSpace(line)
+	if i := strings.LastIndex(line, " +0x"); i >= 0 {
+		line = line[:i]
+	}
+	i := strings.LastIndex(line, ":")
+	if i < 0 {
+		return line, 0
+	}
+	n, _ := strconv.Atoi(line[i+1:])
+	return line[:i], n
+}
@@ -0,0 +1,3 @@
+module channels_10
+
+go 1.22.1
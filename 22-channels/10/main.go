@@ -0,0 +1,76 @@
+package main
+
+import (
+	"channels_10/deadlock"
+	"fmt"
+	"os"
+	"time"
+)
+
+// the same mistake as 22-channels/05: nobody ever receives from ch
+func sendUnbuffered() {
+	ch := make(chan int)
+	ch <- 5
+}
+
+// the same mistake as 23-buffered-channels-and-worker-pools/03: the third write exceeds the capacity
+func sendBuffered() {
+	ch := make(chan string, 2)
+	ch <- "naveen"
+	ch <- "paul"
+	ch <- "steve"
+}
+
+// the same mistake as 24-select/03: a select whose only case can never be ready
+func selectReceive() {
+	ch := make(chan string)
+	select {
+	case <-ch:
+	}
+}
+
+// the same mistake as 24-select/07: a select with no cases blocks forever
+func selectEmpty() {
+	select {}
+}
+
+func main() {
+	// On their own each of these would crash with
+	// "fatal error: all goroutines are asleep - deadlock!"
+	// Here they run in their own Goroutines while main stays awake, so the runtime never notices them.
+	// The monitor dumps the stacks every second and flags anything blocked for 2 seconds or more.
+	m := deadlock.NewMonitor(2*time.Second, time.Second, os.Stdout)
+	m.Start()
+
+	go sendUnbuffered()
+	go sendBuffered()
+	go selectReceive()
+	go selectEmpty()
+
+	time.Sleep(3500 * time.Millisecond)
+	m.Stop()
+
+	fmt.Println("final report")
+	m.Check().WriteTo(os.Stdout)
+}
+
+// blocked on channels: 4 goroutines, 0 stuck longer than 2s
+// [chan send] main.sendUnbuffered main.go:13 (1)
+//     goroutine 7 blocked 1s
+// [chan send] main.sendBuffered main.go:21 (1)
+//     goroutine 8 blocked 1s
+// [chan receive] main.selectReceive main.go:28 (1)
+//     goroutine 9 blocked 1s
+// [select (no cases)] main.selectEmpty main.go:34 (1)
+//     goroutine 10 blocked 1s
+// ...
+// final report
+// blocked on channels: 4 goroutines, 4 stuck longer than 2s
+// [chan send] main.sendUnbuffered main.go:13 (1)
+//     goroutine 7 blocked 3.5s STUCK
+// [chan send] main.sendBuffered main.go:21 (1)
+//     goroutine 8 blocked 3.5s STUCK
+// [chan receive] main.selectReceive main.go:28 (1)
+//     goroutine 9 blocked 3.5s STUCK
+// [select (no cases)] main.selectEmpty main.go:34 (1)
+//     goroutine 10 blocked 3.5s STUCK
@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"goroutines_04/clock"
 	"time"
 )
 
+// both the head start main gives the writer and the slow reader sleep on clk
+var clk clock.Clock = clock.Real()
+
 // takes a channel as an argument
 func write(ch chan int) {
 	for i := 0; i < 5; i++ {
@@ -21,11 +25,11 @@ func main() {
 	ch := make(chan int, 2)
 	// run a Goroutine
 	go write(ch)
-	time.Sleep(2 * time.Second)
+	clk.Sleep(2 * time.Second)
 	// receive the values from the channel
 	for v := range ch {
 		fmt.Println("read value", v, "from ch")
 		// sleep for 2 seconds, this blocks the main Goroutine and any further reading from the channel
-		time.Sleep(2 * time.Second)
+		clk.Sleep(2 * time.Second)
 	}
 }
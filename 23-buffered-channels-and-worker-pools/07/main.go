@@ -2,13 +2,17 @@ package main
 
 import (
 	"fmt"
+	"goroutines_04/clock"
 	"sync"
 	"time"
 )
 
+// process pretends to work by sleeping on clk
+var clk clock.Clock = clock.Real()
+
 func process(i int, wg *sync.WaitGroup) {
 	fmt.Println("started Goroutine ", i)
-	time.Sleep(2 * time.Second)
+	clk.Sleep(2 * time.Second)
 	fmt.Printf("Goroutine %d ended\n", i)
 	// The counter is decremented by the call to wg.Done() in the process Goroutine.
 	// Once all the 3 spawned Goroutines finish their execution,
@@ -2,11 +2,15 @@ package main
 
 import (
 	"fmt"
+	"goroutines_04/clock"
 	"math/rand"
 	"sync"
 	"time"
 )
 
+// digits simulates a slow calculation by sleeping on clk, which a clock.Fake can make instant
+var clk clock.Clock = clock.Real()
+
 // Each `Job` struct has a `id` and a `randomno` for which the sum of the individual digits has to be computed.
 type Job struct {
 	id       int
@@ -35,7 +39,7 @@ func digits(number int) int {
 		sum += digit
 		no /= 10
 	}
-	time.Sleep(2 * time.Second)
+	clk.Sleep(2 * time.Second)
 	return sum
 }
 
@@ -0,0 +1,3 @@
+module buffered_channels_09
+
+go 1.22.1
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"buffered_channels_09/tracedchan"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// the writer from 23-buffered-channels-and-worker-pools/02, without the fmt.Println
+func write(ch *tracedchan.TracedChan[int]) {
+	for i := 0; i < 5; i++ {
+		ch.Send(i)
+	}
+	ch.Close()
+}
+
+func main() {
+	traceFile := flag.String("trace", "", "write a Chrome trace-event file for chrome://tracing or ui.perfetto.dev")
+	jsonFile := flag.String("json", "", "write the timeline as JSON")
+	flag.Parse()
+
+	// a buffered channel with capacity 2, the same as 23-buffered-channels-and-worker-pools/02
+	ch := tracedchan.New[int]("ch", 2)
+	go write(ch)
+
+	// give the writer time to fill the buffer and block on its third send
+	time.Sleep(200 * time.Millisecond)
+	fmt.Printf("len %d, cap %d, blocked senders %d\n", ch.Len(), ch.Cap(), ch.BlockedSenders())
+
+	for {
+		// the slow reader: every receive makes room for one more send
+		v, ok := ch.Receive()
+		if !ok {
+			// like 23-buffered-channels-and-worker-pools/04, a receive on a closed, drained channel returns the zero value and false
+			break
+		}
+		_ = v
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	start := ch.Events()[0].Start
+	for _, e := range ch.Events() {
+		fmt.Printf("%6s goroutine %d %-7s value=%-2s ok=%-5t len=%d blocked %s\n",
+			e.Start.Sub(start).Round(10*time.Millisecond), e.Goroutine, e.Kind, e.Value, e.OK, e.Len, e.Blocked.Round(10*time.Millisecond))
+	}
+
+	if *traceFile != "" {
+		if err := writeFile(*traceFile, ch.WriteChromeTrace); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if *jsonFile != "" {
+		if err := writeFile(*jsonFile, ch.WriteJSON); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
+
+func writeFile(name string, write func(w io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// len 2, cap 2, blocked senders 1
+//     0s goroutine 6 send    value=0  ok=true  len=1 blocked 0s
+//     0s goroutine 6 send    value=1  ok=true  len=2 blocked 0s
+//     0s goroutine 6 send    value=2  ok=true  len=2 blocked 200ms
+//  200ms goroutine 1 receive value=0  ok=true  len=2 blocked 0s
+//  200ms goroutine 6 send    value=3  ok=true  len=2 blocked 200ms
+//  400ms goroutine 1 receive value=1  ok=true  len=2 blocked 0s
+//  400ms goroutine 6 send    value=4  ok=true  len=2 blocked 200ms
+//  600ms goroutine 1 receive value=2  ok=true  len=2 blocked 0s
+//  600ms goroutine 6 close   value=   ok=true  len=2 blocked 0s
+//  800ms goroutine 1 receive value=3  ok=true  len=1 blocked 0s
+//     1s goroutine 1 receive value=4  ok=true  len=0 blocked 0s
+//   1.2s goroutine 1 receive value=   ok=false len=0 blocked 0s
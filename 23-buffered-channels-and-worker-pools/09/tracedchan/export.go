@@ -0,0 +1,68 @@
+package tracedchan
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// timeline is the document written by WriteJSON
+type timeline struct {
+	Channel string  `json:"channel"`
+	Cap     int     `json:"cap"`
+	Events  []Event `json:"events"`
+}
+
+// WriteJSON writes the recorded events to w as a JSON timeline.
+func (c *TracedChan[T]) WriteJSON(w io.Writer) error {
+	events := c.Events()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(timeline{Channel: c.name, Cap: c.Cap(), Events: events})
+}
+
+// traceEvent is one entry of the Chrome trace-event format,
+// which can be loaded into chrome://tracing or https://ui.perfetto.dev
+type traceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Phase string         `json:"ph"`
+	TS    float64        `json:"ts"`
+	Dur   float64        `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int64          `json:"tid"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the recorded events to w in the Chrome trace-event format.
+// Every goroutine gets its own track; sends and receives are drawn as slices as long as they were blocked,
+// closes as instant events, and the buffer length as a counter.
+func (c *TracedChan[T]) WriteChromeTrace(w io.Writer) error {
+	events := c.Events()
+
+	var origin time.Time
+	if len(events) > 0 {
+		origin = events[0].Start
+	}
+	// timestamps are in microseconds since the first event
+	micros := func(t time.Time) float64 {
+		return float64(t.Sub(origin).Nanoseconds()) / 1e3
+	}
+
+	out := make([]traceEvent, 0, 2*len(events))
+	for _, e := range events {
+		name := c.name + " " + string(e.Kind)
+		args := map[string]any{"len": e.Len, "ok": e.OK}
+		if e.Value != "" {
+			args["value"] = e.Value
+		}
+		if e.Kind == Close {
+			out = append(out, traceEvent{Name: name, Cat: c.name, Phase: "i", TS: micros(e.Start), PID: 1, TID: e.Goroutine, Scope: "g", Args: args})
+		} else {
+			out = append(out, traceEvent{Name: name, Cat: c.name, Phase: "X", TS: micros(e.Start), Dur: float64(e.Blocked.Nanoseconds()) / 1e3, PID: 1, TID: e.Goroutine, Args: args})
+		}
+		out = append(out, traceEvent{Name: c.name + " len", Phase: "C", TS: micros(e.End()), PID: 1, Args: map[string]any{"len": e.Len}})
+	}
+	return json.NewEncoder(w).Encode(map[string]any{"traceEvents": out, "displayTimeUnit": "ms"})
+}
@@ -0,0 +1,194 @@
+package tracedchan
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kind is the channel operation an Event records.
+type Kind string
+
+const (
+	Send    Kind = "send"
+	Receive Kind = "receive"
+	Close   Kind = "close"
+)
+
+// An Event is one operation on a TracedChan.
+type Event struct {
+	Kind      Kind      `json:"kind"`
+	Goroutine int64     `json:"goroutine"`
+	Start     time.Time `json:"start"`
+	// Blocked is how long the operation waited before it could complete
+	Blocked time.Duration `json:"blocked_ns"`
+	// Value is the value sent or received, formatted with fmt.Sprint
+	Value string `json:"value,omitempty"`
+	// OK is false for a receive on a closed and drained channel, the same as v, ok := <-ch
+	OK bool `json:"ok"`
+	// Len is the number of values in the buffer right after the operation.
+	// For an operation which had to wait it is read when the goroutine wakes up,
+	// so operations which completed in the meantime are included.
+	Len int `json:"len"`
+}
+
+// End returns the time the operation completed.
+func (e Event) End() time.Time {
+	return e.Start.Add(e.Blocked)
+}
+
+// A TracedChan wraps a channel of type T and records every send, receive and close on it.
+// It can be used in place of chan T wherever a program's behaviour needs to be explained
+// without sprinkling fmt.Println over it.
+type TracedChan[T any] struct {
+	name string
+	ch   chan T
+
+	sending   atomic.Int64
+	receiving atomic.Int64
+
+	mu     sync.Mutex
+	events []Event
+}
+
+// New makes a traced channel with the given buffer capacity, 0 for an unbuffered channel.
+// The name is used to label the channel in exported traces.
+func New[T any](name string, capacity int) *TracedChan[T] {
+	return &TracedChan[T]{
+		name: name,
+		ch:   make(chan T, capacity),
+	}
+}
+
+// Name returns the name the channel was created with.
+func (c *TracedChan[T]) Name() string {
+	return c.name
+}
+
+// Send sends v to the channel, blocking like ch <- v.
+func (c *TracedChan[T]) Send(v T) {
+	start := time.Now()
+	e := Event{Kind: Send, Start: start, Value: fmt.Sprint(v), OK: true}
+
+	// a send which can complete straight away happens under the lock,
+	// so nothing can change the buffer between it and reading its length
+	c.mu.Lock()
+	select {
+	case c.ch <- v:
+		c.add(e)
+		c.mu.Unlock()
+		return
+	default:
+	}
+	c.mu.Unlock()
+
+	// otherwise it has to wait, and only now counts as a blocked sender
+	c.sending.Add(1)
+	c.ch <- v
+	c.sending.Add(-1)
+	e.Blocked = time.Since(start)
+	c.record(e)
+}
+
+// Receive receives a value from the channel, blocking like v, ok := <-ch.
+func (c *TracedChan[T]) Receive() (T, bool) {
+	start := time.Now()
+	e := Event{Kind: Receive, Start: start}
+
+	c.mu.Lock()
+	select {
+	case v, ok := <-c.ch:
+		c.add(received(e, v, ok))
+		c.mu.Unlock()
+		return v, ok
+	default:
+	}
+	c.mu.Unlock()
+
+	c.receiving.Add(1)
+	v, ok := <-c.ch
+	c.receiving.Add(-1)
+	e.Blocked = time.Since(start)
+	c.record(received(e, v, ok))
+	return v, ok
+}
+
+func received[T any](e Event, v T, ok bool) Event {
+	e.OK = ok
+	if ok {
+		e.Value = fmt.Sprint(v)
+	}
+	return e
+}
+
+// Close closes the channel.
+func (c *TracedChan[T]) Close() {
+	start := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	close(c.ch)
+	c.add(Event{Kind: Close, Start: start, OK: true})
+}
+
+// Len returns the number of values queued in the buffer, like len(ch).
+func (c *TracedChan[T]) Len() int {
+	return len(c.ch)
+}
+
+// Cap returns the buffer capacity, like cap(ch).
+func (c *TracedChan[T]) Cap() int {
+	return cap(c.ch)
+}
+
+// BlockedSenders returns the number of goroutines currently waiting in Send for room in the buffer or a receiver.
+// A send which completes straight away is never counted.
+func (c *TracedChan[T]) BlockedSenders() int {
+	return int(c.sending.Load())
+}
+
+// BlockedReceivers returns the number of goroutines currently waiting in Receive for a value or a close.
+// A receive which completes straight away is never counted.
+func (c *TracedChan[T]) BlockedReceivers() int {
+	return int(c.receiving.Load())
+}
+
+// Events returns a copy of every event recorded so far, sorted by the time the operation started.
+func (c *TracedChan[T]) Events() []Event {
+	c.mu.Lock()
+	events := append([]Event(nil), c.events...)
+	c.mu.Unlock()
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events
+}
+
+// record adds the event of an operation which had to wait
+func (c *TracedChan[T]) record(e Event) {
+	c.mu.Lock()
+	c.add(e)
+	c.mu.Unlock()
+}
+
+// add appends e with the calling goroutine and the current buffer length, c.mu must be held
+func (c *TracedChan[T]) add(e Event) {
+	e.Goroutine = goroutineID()
+	e.Len = len(c.ch)
+	c.events = append(c.events, e)
+}
+
+// goroutineID reads the id of the calling goroutine from the first line of its stack trace,
+// "goroutine 18 [running]:". Go deliberately doesn't expose it any other way.
+func goroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseInt(string(buf), 10, 64)
+	return id
+}
@@ -0,0 +1,199 @@
+package tracedchan
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, failing the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	ch := New[int]("ch", 2)
+	ch.Send(1)
+	ch.Send(2)
+	if v, ok := ch.Receive(); v != 1 || !ok {
+		t.Errorf("received %d, %t, want 1, true", v, ok)
+	}
+	ch.Close()
+	ch.Receive()
+	// like v, ok := <-ch on a closed and drained channel
+	if v, ok := ch.Receive(); v != 0 || ok {
+		t.Errorf("received %d, %t from a closed channel, want 0, false", v, ok)
+	}
+
+	want := []Event{
+		{Kind: Send, Value: "1", OK: true, Len: 1},
+		{Kind: Send, Value: "2", OK: true, Len: 2},
+		{Kind: Receive, Value: "1", OK: true, Len: 1},
+		{Kind: Close, OK: true, Len: 1},
+		{Kind: Receive, Value: "2", OK: true, Len: 0},
+		{Kind: Receive, OK: false, Len: 0},
+	}
+	events := ch.Events()
+	if len(events) != len(want) {
+		t.Fatalf("recorded %d events, want %d: %+v", len(events), len(want), events)
+	}
+	g := goroutineID()
+	for i, e := range events {
+		if e.Goroutine != g {
+			t.Errorf("event %d is from goroutine %d, want %d", i, e.Goroutine, g)
+		}
+		// none of them had to wait
+		if e.Blocked != 0 {
+			t.Errorf("event %d was blocked for %s", i, e.Blocked)
+		}
+		e.Goroutine, e.Start = 0, time.Time{}
+		if e != want[i] {
+			t.Errorf("event %d is %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestBlockedSenders(t *testing.T) {
+	ch := New[string]("ch", 1)
+	// fills the buffer without waiting
+	ch.Send("a")
+	if n := ch.BlockedSenders(); n != 0 {
+		t.Errorf("%d blocked senders after a send into the buffer, want 0", n)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ch.Send("b")
+		close(done)
+	}()
+	waitFor(t, "a blocked sender", func() bool { return ch.BlockedSenders() == 1 })
+	time.Sleep(10 * time.Millisecond)
+
+	// taking "a" lets "b" into the buffer
+	ch.Receive()
+	<-done
+	if n := ch.BlockedSenders(); n != 0 {
+		t.Errorf("%d blocked senders once the send completed, want 0", n)
+	}
+	// events are sorted by start, and the send of b started before the receive which let it through
+	events := ch.Events()
+	if e := events[1]; e.Kind != Send || e.Value != "b" || e.Blocked < 10*time.Millisecond || e.Len != 1 {
+		t.Errorf("the waiting send recorded %+v, want b blocked for at least 10ms with 1 in the buffer", e)
+	}
+}
+
+func TestBlockedReceivers(t *testing.T) {
+	ch := New[int]("ch", 0)
+	got := make(chan int)
+	for i := 0; i < 3; i++ {
+		go func() {
+			v, _ := ch.Receive()
+			got <- v
+		}()
+	}
+	waitFor(t, "three blocked receivers", func() bool { return ch.BlockedReceivers() == 3 })
+
+	// a receiver is waiting, so the send completes straight away
+	ch.Send(7)
+	if v := <-got; v != 7 {
+		t.Errorf("received %d, want 7", v)
+	}
+	if n := ch.BlockedSenders(); n != 0 {
+		t.Errorf("%d blocked senders, want 0", n)
+	}
+	waitFor(t, "two blocked receivers", func() bool { return ch.BlockedReceivers() == 2 })
+
+	ch.Close()
+	<-got
+	<-got
+	if n := ch.BlockedReceivers(); n != 0 {
+		t.Errorf("%d blocked receivers after the close, want 0", n)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	ch := New[int]("jobs", 1)
+	ch.Send(4)
+	ch.Receive()
+	ch.Close()
+
+	var buf bytes.Buffer
+	if err := ch.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got timeline
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v in\n%s", err, buf.Bytes())
+	}
+	if got.Channel != "jobs" || got.Cap != 1 || len(got.Events) != 3 {
+		t.Fatalf("got %+v, want channel jobs with capacity 1 and 3 events", got)
+	}
+	for i, e := range ch.Events() {
+		if !got.Events[i].Start.Equal(e.Start) {
+			t.Errorf("event %d starts at %s, want %s", i, got.Events[i].Start, e.Start)
+		}
+		got.Events[i].Start = e.Start
+		if got.Events[i] != e {
+			t.Errorf("event %d is %+v, want %+v", i, got.Events[i], e)
+		}
+	}
+}
+
+func TestWriteChromeTrace(t *testing.T) {
+	ch := New[int]("jobs", 1)
+	ch.Send(4)
+	ch.Receive()
+	ch.Close()
+
+	var buf bytes.Buffer
+	if err := ch.WriteChromeTrace(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		TraceEvents     []traceEvent `json:"traceEvents"`
+		DisplayTimeUnit string       `json:"displayTimeUnit"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v in\n%s", err, buf.Bytes())
+	}
+	if got.DisplayTimeUnit != "ms" {
+		t.Errorf("display time unit %q, want ms", got.DisplayTimeUnit)
+	}
+
+	// every operation is followed by a counter of the buffer length
+	want := []struct {
+		name, phase string
+		len         float64
+	}{
+		{"jobs send", "X", 1}, {"jobs len", "C", 1},
+		{"jobs receive", "X", 0}, {"jobs len", "C", 0},
+		{"jobs close", "i", 0}, {"jobs len", "C", 0},
+	}
+	if len(got.TraceEvents) != len(want) {
+		t.Fatalf("got %d trace events, want %d:\n%s", len(got.TraceEvents), len(want), buf.Bytes())
+	}
+	g := goroutineID()
+	for i, w := range want {
+		e := got.TraceEvents[i]
+		if e.Name != w.name || e.Phase != w.phase || e.Args["len"] != w.len {
+			t.Errorf("trace event %d is %+v, want %s %s with len %v", i, e, w.phase, w.name, w.len)
+		}
+		if e.Phase != "C" && e.TID != g {
+			t.Errorf("trace event %d is on track %d, want the goroutine %d", i, e.TID, g)
+		}
+		if i > 0 && e.TS < got.TraceEvents[i-1].TS {
+			t.Errorf("trace event %d at %vµs is before the one at %vµs", i, e.TS, got.TraceEvents[i-1].TS)
+		}
+	}
+	if v := got.TraceEvents[0].Args["value"]; v != "4" {
+		t.Errorf("the send has value %v, want 4", v)
+	}
+}
@@ -0,0 +1,3 @@
+module buffered_channels_10
+
+go 1.22.1
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"buffered_channels_10/supervisor"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// digits from 23-buffered-channels-and-worker-pools/08 with a bug: it panics on zero
+func digits(number int) int {
+	if number == 0 {
+		panic("digits of zero")
+	}
+	sum := 0
+	for no := number; no != 0; no /= 10 {
+		sum += no % 10
+	}
+	return sum
+}
+
+// worker reads jobs until the channel is closed, like the worker in 08.
+// When digits panics, the supervisor restarts the worker and it carries on with the next job.
+// The job it was on when it panicked is reported as failed when it restarts, so no job goes missing from the results.
+func worker(jobs chan int, results chan string) func(ctx context.Context) error {
+	// inFlight is the job being worked on. It outlives a panic because it belongs to the worker, not to one run of it.
+	// The supervisor only restarts a run after the last one has returned, so the runs never use it at the same time.
+	var inFlight *int
+	return func(ctx context.Context) error {
+		if inFlight != nil {
+			results <- fmt.Sprintf("sum of digits of %d failed", *inFlight)
+			inFlight = nil
+		}
+		for number := range jobs {
+			inFlight = &number
+			results <- fmt.Sprintf("sum of digits of %d is %d", number, digits(number))
+			inFlight = nil
+		}
+		return nil
+	}
+}
+
+func main() {
+	// 1. one-for-one: a panicking worker is restarted on its own, the pool finishes every other job
+	jobs := make(chan int, 10)
+	results := make(chan string, 10)
+	for _, n := range []int{123, 0, 456, 0, 789} {
+		jobs <- n
+	}
+	close(jobs)
+
+	s := supervisor.New(supervisor.OneForOne, 5, time.Second)
+	s.Notify = func(e supervisor.Event) {
+		if e.Err != nil {
+			fmt.Printf("%v, restarting: %t\n", e.Err, e.Restarting)
+		}
+	}
+	s.Add(supervisor.Child{Name: "worker", Run: worker(jobs, results), Restart: supervisor.Transient})
+	fmt.Println("pool finished, error:", s.Run(context.Background()))
+	close(results)
+	for r := range results {
+		fmt.Println(r)
+	}
+
+	// 2. the restart intensity: a child that fails every time is given up on after 3 restarts in a second
+	s = supervisor.New(supervisor.OneForOne, 3, time.Second)
+	s.Add(supervisor.Child{Name: "flaky", Run: func(ctx context.Context) error {
+		return errors.New("cannot connect")
+	}})
+	err := s.Run(context.Background())
+	var tooMany *supervisor.TooManyRestartsError
+	fmt.Println(errors.As(err, &tooMany), err)
+
+	// 3. one-for-all: when the reader crashes, the writer feeding it is restarted with it
+	s = supervisor.New(supervisor.OneForAll, 1, time.Second)
+	s.Notify = func(e supervisor.Event) {
+		fmt.Printf("%s returned, error: %v, restarting: %t\n", e.Child, e.Err, e.Restarting)
+	}
+	var writerStarts, readerStarts atomic.Int32
+	s.Add(supervisor.Child{Name: "writer", Run: func(ctx context.Context) error {
+		writerStarts.Add(1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+			return nil
+		}
+	}})
+	s.Add(supervisor.Child{Name: "reader", Run: func(ctx context.Context) error {
+		if readerStarts.Add(1) == 1 {
+			var m map[string]int
+			m["boom"] = 1
+		}
+		return nil
+	}})
+	s.Run(context.Background())
+	fmt.Println("writer started", writerStarts.Load(), "times, reader started", readerStarts.Load(), "times")
+}
+
+// worker panicked: digits of zero, restarting: true
+// worker panicked: digits of zero, restarting: true
+// pool finished, error: <nil>
+// sum of digits of 123 is 6
+// sum of digits of 0 failed
+// sum of digits of 456 is 15
+// sum of digits of 0 failed
+// sum of digits of 789 is 24
+// true supervisor: more than 3 restarts in 1s, last failure: cannot connect
+// reader returned, error: reader panicked: assignment to entry in nil map, restarting: true
+// reader returned, error: <nil>, restarting: false
+// writer returned, error: <nil>, restarting: false
+// writer started 2 times, reader started 2 times
@@ -0,0 +1,56 @@
+package supervisor
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// A PanicError is what a child Goroutine's panic is turned into.
+// The rest of the program keeps running; only the child that panicked is stopped.
+type PanicError struct {
+	// Child is the name of the Goroutine that panicked
+	Child string
+	Value any
+	// Stack is the stack trace of the Goroutine at the point it panicked
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("%s panicked: %v", p.Child, p.Value)
+}
+
+// Unwrap returns the panic value if it was an error, such as a runtime error from an out of range index.
+func (p *PanicError) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// A TooManyRestartsError is returned by Run when children had to be restarted
+// more often than the supervisor's restart intensity allows.
+type TooManyRestartsError struct {
+	Restarts int
+	Period   time.Duration
+	// Last is the error of the child whose failure used up the last restart
+	Last error
+}
+
+func (t *TooManyRestartsError) Error() string {
+	return fmt.Sprintf("supervisor: more than %d restarts in %s, last failure: %v", t.Restarts, t.Period, t.Last)
+}
+
+func (t *TooManyRestartsError) Unwrap() error {
+	return t.Last
+}
+
+// Recover calls fn and returns a *PanicError if it panics.
+// It is for Goroutines that are not run by a Supervisor, such as the ones in a sync.WaitGroup example,
+// so that one panic is reported instead of killing the process.
+func Recover(name string, fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Child: name, Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,194 @@
+package supervisor
+
+import (
+	"context"
+	"time"
+)
+
+// Strategy decides which children are restarted when one of them fails.
+type Strategy int
+
+const (
+	// OneForOne restarts only the child that failed.
+	OneForOne Strategy = iota
+	// OneForAll stops every other child and then restarts all of them,
+	// for children that depend on each other and cannot carry on alone.
+	OneForAll
+)
+
+// Restart decides whether a child is restarted when it returns.
+type Restart int
+
+const (
+	// Transient children are restarted when they panic or return an error, but not when they return nil.
+	Transient Restart = iota
+	// Permanent children are always restarted, even when they return nil.
+	Permanent
+	// Temporary children are never restarted.
+	Temporary
+)
+
+// A Child is a Goroutine run by a Supervisor.
+// Run should return when ctx is done, which is how the supervisor stops it.
+type Child struct {
+	Name    string
+	Run     func(ctx context.Context) error
+	Restart Restart
+}
+
+// An Event tells the supervisor's Notify function that a child returned.
+type Event struct {
+	Child string
+	// Err is nil if the child returned normally, and a *PanicError if it panicked
+	Err error
+	// Restarting is set if the supervisor is about to start the child again
+	Restarting bool
+}
+
+// A Supervisor runs a fixed set of children, recovers their panics and restarts them according to its Strategy.
+// To stop a child that keeps crashing from being restarted forever, a Supervisor allows at most
+// maxRestarts restarts within a period; one more and it gives up, stops every child and returns a *TooManyRestartsError.
+type Supervisor struct {
+	strategy    Strategy
+	maxRestarts int
+	period      time.Duration
+	children    []Child
+
+	// Notify, if set, is called from Run every time a child returns
+	Notify func(Event)
+}
+
+// New creates a Supervisor which allows at most maxRestarts restarts within period.
+func New(strategy Strategy, maxRestarts int, period time.Duration) *Supervisor {
+	return &Supervisor{strategy: strategy, maxRestarts: maxRestarts, period: period}
+}
+
+// Add adds a child. Children are started in the order they were added when Run is called.
+func (s *Supervisor) Add(c Child) {
+	s.children = append(s.children, c)
+}
+
+// exit is sent by a child's Goroutine when it returns
+type exit struct {
+	child int
+	err   error
+}
+
+// Run starts every child and supervises them until they have all returned for good,
+// the restart intensity is exceeded, or ctx is cancelled.
+func (s *Supervisor) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	exits := make(chan exit)
+	// the cancel func of every child that has been started and has not returned yet
+	live := make(map[int]context.CancelFunc)
+	start := func(i int) {
+		cctx, ccancel := context.WithCancel(ctx)
+		live[i] = ccancel
+		c := s.children[i]
+		go func() {
+			err := Recover(c.Name, func() error { return c.Run(cctx) })
+			exits <- exit{child: i, err: err}
+		}()
+	}
+	// stop cancels the given children and waits for them to return
+	stop := func(children []int) {
+		waiting := make(map[int]bool, len(children))
+		for _, i := range children {
+			live[i]()
+			waiting[i] = true
+		}
+		for len(waiting) > 0 {
+			e := <-exits
+			delete(live, e.child)
+			delete(waiting, e.child)
+		}
+	}
+	stopAll := func() {
+		var all []int
+		for i := range live {
+			all = append(all, i)
+		}
+		stop(all)
+	}
+
+	for i := range s.children {
+		start(i)
+	}
+
+	var restarts []time.Time
+	for len(live) > 0 {
+		var e exit
+		select {
+		case e = <-exits:
+			if ctx.Err() != nil {
+				// the child most likely returned because it was cancelled, so it is not restarted
+				delete(live, e.child)
+				stopAll()
+				return ctx.Err()
+			}
+		case <-ctx.Done():
+			stopAll()
+			return ctx.Err()
+		}
+		live[e.child]()
+		delete(live, e.child)
+
+		c := s.children[e.child]
+		restart := c.Restart == Permanent || (c.Restart == Transient && e.err != nil)
+		if !restart {
+			s.notify(Event{Child: c.Name, Err: e.err})
+			continue
+		}
+
+		// only count the restarts inside the sliding window
+		now := time.Now()
+		recent := restarts[:0]
+		for _, t := range restarts {
+			if now.Sub(t) < s.period {
+				recent = append(recent, t)
+			}
+		}
+		restarts = append(recent, now)
+		if len(restarts) > s.maxRestarts {
+			s.notify(Event{Child: c.Name, Err: e.err})
+			stopAll()
+			return &TooManyRestartsError{Restarts: s.maxRestarts, Period: s.period, Last: e.err}
+		}
+		s.notify(Event{Child: c.Name, Err: e.err, Restarting: true})
+
+		switch s.strategy {
+		case OneForOne:
+			start(e.child)
+		case OneForAll:
+			var others []int
+			for i := range live {
+				others = append(others, i)
+			}
+			stop(others)
+			// the failed child and every child that was still running start again, in their original order
+			for i := range s.children {
+				if i == e.child || contains(others, i) {
+					start(i)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Supervisor) notify(e Event) {
+	if s.Notify != nil {
+		s.Notify(e)
+	}
+}
+
+func contains(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var errBoom = errors.New("boom")
+
+// failing returns a Run func which fails the first n times it is called, by panicking if panics is set,
+// and then returns nil. runs counts the calls.
+func failing(n int, panics bool, runs *int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*runs++
+		if *runs > n {
+			return nil
+		}
+		if panics {
+			panic("crash")
+		}
+		return errBoom
+	}
+}
+
+// blocking returns a Run func which runs until it is stopped, counting how often it was started and stopped
+func blocking(started, stopped *int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*started++
+		<-ctx.Done()
+		*stopped++
+		return ctx.Err()
+	}
+}
+
+// event is an Event without its error, which is checked separately
+type event struct {
+	child      string
+	restarting bool
+}
+
+// record sets s.Notify to collect its events.
+// Notify is called from Run, so the slices are safe to read once Run has returned.
+func record(s *Supervisor) (*[]event, *[]error) {
+	var events []event
+	var errs []error
+	s.Notify = func(e Event) {
+		events = append(events, event{e.Child, e.Restarting})
+		errs = append(errs, e.Err)
+	}
+	return &events, &errs
+}
+
+func TestRestartOnPanic(t *testing.T) {
+	s := New(OneForOne, 5, time.Hour)
+	var runs int
+	s.Add(Child{Name: "worker", Run: failing(2, true, &runs), Restart: Transient})
+	events, errs := record(s)
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Run = %v, want nil once the worker stopped crashing", err)
+	}
+	if runs != 3 {
+		t.Errorf("the worker ran %d times, want 3", runs)
+	}
+	want := []event{{"worker", true}, {"worker", true}, {"worker", false}}
+	if !reflect.DeepEqual(*events, want) {
+		t.Errorf("events %v, want %v", *events, want)
+	}
+	for i, err := range (*errs)[:2] {
+		var perr *PanicError
+		if !errors.As(err, &perr) || perr.Child != "worker" || perr.Value != "crash" || len(perr.Stack) == 0 {
+			t.Errorf("event %d has error %#v, want the worker's panic", i, err)
+		}
+	}
+	if err := (*errs)[2]; err != nil {
+		t.Errorf("the last event has error %v, want nil", err)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		restart Restart
+		// fails is how often the child fails before returning nil
+		fails int
+		runs  int
+	}{
+		{"transient child failing", Transient, 2, 3},
+		{"transient child returning", Transient, 0, 1},
+		{"temporary child failing", Temporary, 2, 1},
+		// a permanent child is restarted until it uses up the restarts
+		{"permanent child returning", Permanent, 0, 4},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(OneForOne, 3, time.Hour)
+			var runs int
+			s.Add(Child{Name: "worker", Run: failing(tt.fails, false, &runs), Restart: tt.restart})
+			err := s.Run(context.Background())
+			if runs != tt.runs {
+				t.Errorf("ran %d times, want %d", runs, tt.runs)
+			}
+			var tooMany *TooManyRestartsError
+			if errors.As(err, &tooMany) != (tt.restart == Permanent) {
+				t.Errorf("Run = %v", err)
+			}
+		})
+	}
+}
+
+func TestOneForAll(t *testing.T) {
+	s := New(OneForAll, 5, time.Hour)
+	var runs, started, stopped int
+	s.Add(Child{Name: "a", Run: failing(1, false, &runs), Restart: Transient})
+	// b starts before a fails, so it is stopped and started again with it
+	s.Add(Child{Name: "b", Run: blocking(&started, &stopped), Restart: Transient})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Notify = func(e Event) {
+		// a returned for good, and b is running for the second time
+		if e.Child == "a" && !e.Restarting {
+			cancel()
+		}
+	}
+	if err := s.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run = %v, want context.Canceled", err)
+	}
+	if runs != 2 || started != 2 || stopped != 2 {
+		t.Errorf("a ran %d times, b was started %d and stopped %d times, want 2 each", runs, started, stopped)
+	}
+}
+
+func TestTooManyRestarts(t *testing.T) {
+	s := New(OneForOne, 2, time.Hour)
+	var runs, started, stopped int
+	s.Add(Child{Name: "sibling", Run: blocking(&started, &stopped), Restart: Permanent})
+	s.Add(Child{Name: "worker", Run: failing(10, false, &runs), Restart: Transient})
+	events, _ := record(s)
+
+	err := s.Run(context.Background())
+	var tooMany *TooManyRestartsError
+	if !errors.As(err, &tooMany) {
+		t.Fatalf("Run = %v, want a *TooManyRestartsError", err)
+	}
+	if tooMany.Restarts != 2 || tooMany.Period != time.Hour || !errors.Is(err, errBoom) {
+		t.Errorf("Run = %#v, want 2 restarts in 1h, the last caused by %v", tooMany, errBoom)
+	}
+	// two restarts are allowed, the third failure escalates
+	if runs != 3 {
+		t.Errorf("the worker ran %d times, want 3", runs)
+	}
+	want := []event{{"worker", true}, {"worker", true}, {"worker", false}}
+	if !reflect.DeepEqual(*events, want) {
+		t.Errorf("events %v, want %v", *events, want)
+	}
+	// escalating stops every other child, and Run waits for them
+	if started != 1 || stopped != 1 {
+		t.Errorf("the sibling was started %d and stopped %d times, want 1 each", started, stopped)
+	}
+}
+
+func TestRestartsOutsideThePeriodDontCount(t *testing.T) {
+	// with a period of 0 every earlier restart is outside the window,
+	// so a child can fail any number of times one after the other
+	s := New(OneForOne, 1, 0)
+	var runs int
+	s.Add(Child{Name: "worker", Run: failing(5, false, &runs), Restart: Transient})
+	if err := s.Run(context.Background()); err != nil {
+		t.Errorf("Run = %v, want nil", err)
+	}
+	if runs != 6 {
+		t.Errorf("the worker ran %d times, want 6", runs)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	s := New(OneForOne, 1, time.Hour)
+	var started, stopped [2]int
+	for i := range started {
+		s.Add(Child{Name: "worker", Run: blocking(&started[i], &stopped[i]), Restart: Permanent})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run = %v, want context.DeadlineExceeded", err)
+	}
+	// the children returned because they were cancelled, not because they failed, so they are not restarted
+	if started != [2]int{1, 1} || stopped != [2]int{1, 1} {
+		t.Errorf("the children were started %v and stopped %v times, want once each", started, stopped)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	if err := Recover("ok", func() error { return errBoom }); err != errBoom {
+		t.Errorf("Recover returned %v, want the function's own error", err)
+	}
+	err := Recover("indexer", func() error {
+		var s []int
+		s[1] = 1
+		return nil
+	})
+	var perr *PanicError
+	if !errors.As(err, &perr) || perr.Child != "indexer" {
+		t.Fatalf("Recover returned %#v, want a *PanicError from indexer", err)
+	}
+	if err.Error() != "indexer panicked: runtime error: index out of range [1] with length 0" {
+		t.Errorf("error %q", err)
+	}
+}
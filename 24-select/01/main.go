@@ -2,12 +2,16 @@ package main
 
 import (
 	"fmt"
+	"goroutines_04/clock"
 	"time"
 )
 
+// the response time of each server is a sleep on clk
+var clk clock.Clock = clock.Real()
+
 func server1(ch chan string) {
 	// Sleeps for 6 seconds
-	time.Sleep(6 * time.Second)
+	clk.Sleep(6 * time.Second)
 
 	// Writes the text "from server1" to the channel `ch`
 	ch <- "from server1"
@@ -15,7 +19,7 @@ func server1(ch chan string) {
 
 func server2(ch chan string) {
 	// Sleeps for 3 seconds
-	time.Sleep(3 * time.Second)
+	clk.Sleep(3 * time.Second)
 
 	// Writes the text "from server2" to the channel `ch`
 	ch <- "from server2"
@@ -2,12 +2,16 @@ package main
 
 import (
 	"fmt"
+	"goroutines_04/clock"
 	"time"
 )
 
+// process and the polling loop in main both sleep on clk
+var clk clock.Clock = clock.Real()
+
 func process(ch chan string) {
 	// Sleep for 10.5 seconds before sending value to channel
-	time.Sleep(10500 * time.Millisecond)
+	clk.Sleep(10500 * time.Millisecond)
 	ch <- "process successful"
 }
 
@@ -18,7 +22,7 @@ func main() {
 
 	for {
 		// Sleep for 1 second at the start of each iteration
-		time.Sleep(1000 * time.Millisecond)
+		clk.Sleep(1000 * time.Millisecond)
 		select {
 		case v := <-ch:
 			// If a value is received from the channel, print it and exit
@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"goroutines_04/clock"
 	"time"
 )
 
+// main sleeps on clk to give the servers time to send
+var clk clock.Clock = clock.Real()
+
 func server1(ch chan string) {
 	// send "from server1" to the channel
 	ch <- "from server1"
@@ -25,7 +29,7 @@ func main() {
 	go server2(output2)
 
 	// sleep for 1 second to allow the goroutines to execute
-	time.Sleep(1 * time.Second)
+	clk.Sleep(1 * time.Second)
 
 	// Random selection
 	// When multiple cases in a `select` statement are ready, one of them will be executed at random.
@@ -0,0 +1,207 @@
+package counter
+
+import (
+	"errors"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// A Counter is the shared x from 25-mutex, made safe to increment from many Goroutines at once.
+// Every implementation gives the same answer; they differ in how fast they get there under contention.
+type Counter interface {
+	// Inc adds 1, the x = x + 1 of the mutex examples
+	Inc()
+	Add(n int64)
+	Value() int64
+}
+
+// Mutex is the fix from 25-mutex/02: a sync.Mutex guarding a plain int.
+type Mutex struct {
+	mu sync.Mutex
+	n  int64
+}
+
+// NewMutex returns a Counter guarded by a sync.Mutex.
+func NewMutex() *Mutex {
+	return &Mutex{}
+}
+
+func (c *Mutex) Inc() {
+	c.Add(1)
+}
+
+func (c *Mutex) Add(n int64) {
+	c.mu.Lock()
+	c.n += n
+	c.mu.Unlock()
+}
+
+func (c *Mutex) Value() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+// Atomic makes the read, add and write of x one indivisible CPU instruction, so no lock is needed.
+type Atomic struct {
+	n atomic.Int64
+}
+
+// NewAtomic returns a Counter built on sync/atomic.
+func NewAtomic() *Atomic {
+	return &Atomic{}
+}
+
+func (c *Atomic) Inc() {
+	c.n.Add(1)
+}
+
+func (c *Atomic) Add(n int64) {
+	c.n.Add(n)
+}
+
+func (c *Atomic) Value() int64 {
+	return c.n.Load()
+}
+
+// ErrClosed is what an Actor panics with when it is used after Close.
+// Without the owning Goroutine nobody would ever answer, and the caller would block forever instead.
+var ErrClosed = errors.New("counter: Actor used after Close")
+
+// Actor solves the race the way the 25-mutex README says channels can:
+// a single Goroutine owns the count and everyone else sends it messages.
+// Since only that Goroutine ever touches the count, there is nothing to lock.
+type Actor struct {
+	adds   chan int64
+	reads  chan chan int64
+	closed chan struct{}
+}
+
+// NewActor starts the Goroutine which owns the count. Call Close to stop it.
+func NewActor() *Actor {
+	c := &Actor{
+		adds:   make(chan int64, 128),
+		reads:  make(chan chan int64),
+		closed: make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+func (c *Actor) run() {
+	var n int64
+	for {
+		select {
+		case d := <-c.adds:
+			n += d
+		case reply := <-c.reads:
+			// apply the adds already queued before answering, so a Value after an Add always sees it
+			for len(c.adds) > 0 {
+				n += <-c.adds
+			}
+			reply <- n
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *Actor) Inc() {
+	c.Add(1)
+}
+
+// Add sends n to the owning Goroutine. It panics with ErrClosed after Close.
+func (c *Actor) Add(n int64) {
+	c.checkOpen()
+	select {
+	case c.adds <- n:
+	case <-c.closed:
+		panic(ErrClosed)
+	}
+}
+
+// Value asks the owning Goroutine for the count. It panics with ErrClosed after Close.
+func (c *Actor) Value() int64 {
+	c.checkOpen()
+	reply := make(chan int64)
+	select {
+	case c.reads <- reply:
+		return <-reply
+	case <-c.closed:
+		panic(ErrClosed)
+	}
+}
+
+// checkOpen panics if Close has run. Add needs it because adds is buffered:
+// when both cases of its select are ready, select picks one at random and the add could be queued for nobody.
+func (c *Actor) checkOpen() {
+	select {
+	case <-c.closed:
+		panic(ErrClosed)
+	default:
+	}
+}
+
+// Close stops the owning Goroutine. Using the counter afterwards panics with ErrClosed.
+func (c *Actor) Close() {
+	close(c.closed)
+}
+
+// shard is one slot of a Sharded counter, padded out to a cache line of its own
+// so that CPUs updating neighbouring shards don't fight over the same line (false sharing)
+type shard struct {
+	n atomic.Int64
+	_ [56]byte
+}
+
+// Sharded spreads the count over one atomic counter per CPU.
+// Each increment goes to a randomly picked shard, so Goroutines running on different CPUs rarely touch the same memory.
+// Value adds the shards up, which makes reads slower; it is the right choice for counters written far more than read.
+type Sharded struct {
+	shards []shard
+}
+
+// NewSharded returns a Counter with one shard per CPU the program may use, or shards shards if it is positive.
+func NewSharded(shards int) *Sharded {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	return &Sharded{shards: make([]shard, shards)}
+}
+
+func (c *Sharded) Inc() {
+	c.Add(1)
+}
+
+func (c *Sharded) Add(n int64) {
+	// Go has no way to ask which CPU a Goroutine is on; a random shard spreads the load just as well
+	c.shards[rand.IntN(len(c.shards))].n.Add(n)
+}
+
+// Value returns the sum of the shards. Adds that happen while it is summing may or may not be included.
+func (c *Sharded) Value() int64 {
+	var total int64
+	for i := range c.shards {
+		total += c.shards[i].n.Load()
+	}
+	return total
+}
+
+// An Implementation names one of the counters in this package and creates new ones,
+// for programs and benchmarks which compare them all.
+type Implementation struct {
+	Name string
+	New  func() Counter
+}
+
+// Implementations returns every counter in this package, in the order they are described above.
+func Implementations() []Implementation {
+	return []Implementation{
+		{"mutex", func() Counter { return NewMutex() }},
+		{"atomic", func() Counter { return NewAtomic() }},
+		{"actor", func() Counter { return NewActor() }},
+		{"sharded", func() Counter { return NewSharded(0) }},
+	}
+}
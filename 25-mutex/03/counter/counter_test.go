@@ -0,0 +1,91 @@
+package counter_test
+
+import (
+	"fmt"
+	"mutex_03/counter"
+	"sync"
+	"testing"
+)
+
+// closeCounter stops the Goroutine behind an actor counter
+func closeCounter(c counter.Counter) {
+	if a, ok := c.(*counter.Actor); ok {
+		a.Close()
+	}
+}
+
+// run starts one Goroutine per increment and waits for all of them, like the 25-mutex program
+func run(c counter.Counter, goroutines int) {
+	var w sync.WaitGroup
+	w.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			c.Inc()
+			w.Done()
+		}()
+	}
+	w.Wait()
+}
+
+func TestCounters(t *testing.T) {
+	for _, impl := range counter.Implementations() {
+		t.Run(impl.Name, func(t *testing.T) {
+			c := impl.New()
+			defer closeCounter(c)
+			run(c, 1000)
+			c.Add(-10)
+			if got := c.Value(); got != 990 {
+				t.Errorf("after 1000 increments and adding -10 the value is %d, want 990", got)
+			}
+		})
+	}
+}
+
+func TestActorAfterClose(t *testing.T) {
+	for _, op := range []struct {
+		name string
+		use  func(c *counter.Actor)
+	}{
+		{"Add", func(c *counter.Actor) { c.Add(1) }},
+		{"Inc", func(c *counter.Actor) { c.Inc() }},
+		{"Value", func(c *counter.Actor) { c.Value() }},
+	} {
+		t.Run(op.name, func(t *testing.T) {
+			c := counter.NewActor()
+			c.Close()
+			defer func() {
+				if v := recover(); v != counter.ErrClosed {
+					t.Errorf("%s after Close panicked with %v, want %v", op.name, v, counter.ErrClosed)
+				}
+			}()
+			op.use(c)
+		})
+	}
+}
+
+// BenchmarkCounters times one round of starting n Goroutines that increment once each.
+// Divide ns/op by n for the cost of one increment.
+// A million Goroutines take a few seconds a round, so -short stops at 100000.
+//
+//	go test -bench . ./counter
+func BenchmarkCounters(b *testing.B) {
+	most := 1000000
+	if testing.Short() {
+		most = 100000
+	}
+	for n := 1000; n <= most; n *= 10 {
+		for _, impl := range counter.Implementations() {
+			b.Run(fmt.Sprintf("%s/%d", impl.Name, n), func(b *testing.B) {
+				c := impl.New()
+				defer closeCounter(c)
+				for i := 0; i < b.N; i++ {
+					run(c, n)
+				}
+				b.StopTimer()
+				if got, want := c.Value(), int64(n*b.N); got != want {
+					b.Fatalf("%s counted %d, want %d", impl.Name, got, want)
+				}
+			})
+		}
+	}
+}
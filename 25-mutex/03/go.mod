@@ -0,0 +1,3 @@
+module mutex_03
+
+go 1.22.1
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"mutex_03/counter"
+	"sync"
+)
+
+// increment is the increment function from 25-mutex, with x replaced by a Counter
+func increment(wg *sync.WaitGroup, c counter.Counter) {
+	c.Inc()
+	wg.Done()
+}
+
+// The 25-mutex program itself, run with every counter: 1000 Goroutines, and every counter must end at 1000.
+// How fast each one gets there is measured by the benchmarks of the counter package:
+//
+//	go test -bench . ./counter
+func main() {
+	for _, impl := range counter.Implementations() {
+		c := impl.New()
+		var w sync.WaitGroup
+		for i := 0; i < 1000; i++ {
+			w.Add(1)
+			go increment(&w, c)
+		}
+		w.Wait()
+		fmt.Printf("%-8s final value of x %d\n", impl.Name, c.Value())
+		if a, ok := c.(*counter.Actor); ok {
+			// stop the Goroutine which owns the count
+			a.Close()
+		}
+	}
+}
+
+// mutex    final value of x 1000
+// atomic   final value of x 1000
+// actor    final value of x 1000
+// sharded  final value of x 1000
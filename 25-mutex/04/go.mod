@@ -0,0 +1,3 @@
+module mutex_04
+
+go 1.22.1
@@ -0,0 +1,147 @@
+// Package racecheck builds example programs of the tree with the race detector and runs them again and again,
+// checking that the racy ones are caught and the fixed ones never are.
+// The examples it checks are the scenarios in racecheck_test.go:
+//
+//	go test ./...
+package racecheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// A Scenario is one example program and what must be true every time it runs under the race detector.
+type Scenario struct {
+	Name string
+	// Dir is the directory of the example's go.mod, relative to the repository root
+	Dir string
+	// Runs is how many times to run the program; races and bad interleavings don't show up every time
+	Runs int
+	// WantRace means the race detector must flag the program in at least one run.
+	// Without it, every run must be free of races.
+	WantRace bool
+	// Stdout, if set, must match the output of every run
+	Stdout *regexp.Regexp
+	// Stderr, if set, must match the error output of every run,
+	// for examples such as 22-channels/05 which are meant to crash
+	Stderr *regexp.Regexp
+	// WantFail means the program is expected to exit with an error, such as a deadlock.
+	// A race is reported with exit status 66 and doesn't count as failing.
+	WantFail bool
+	// NoRace builds the program without the race detector.
+	// Deadlock examples need it: the race detector runs a background thread of its own,
+	// so the runtime never finds all goroutines asleep and the program hangs instead of crashing.
+	NoRace bool
+	// Timeout limits each run, 30 seconds if zero. A run that times out is a problem.
+	Timeout time.Duration
+}
+
+// A Result is what happened when a Scenario was checked.
+type Result struct {
+	Scenario Scenario
+	// Races is the number of runs the race detector flagged
+	Races int
+	// Problems lists every way the runs did not match the Scenario; the Scenario passed if it is empty
+	Problems []string
+}
+
+// Passed reports whether the scenario held in every run.
+func (r Result) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+// the exit status of a program built with -race which found a race, see GORACE=exitcode
+const raceExitCode = 66
+
+var raceWarning = []byte("WARNING: DATA RACE")
+
+// Check builds the scenario's program once, with -race unless NoRace is set, runs it s.Runs times and checks every run.
+// root is the repository root the scenario's Dir is relative to.
+func Check(ctx context.Context, root string, s Scenario) (Result, error) {
+	res := Result{Scenario: s}
+	tmp, err := os.MkdirTemp("", "racecheck")
+	if err != nil {
+		return res, err
+	}
+	defer os.RemoveAll(tmp)
+
+	bin := filepath.Join(tmp, "example")
+	args := []string{"build", "-o", bin}
+	if !s.NoRace {
+		args = append(args, "-race")
+	}
+	build := exec.CommandContext(ctx, "go", append(args, ".")...)
+	build.Dir = filepath.Join(root, s.Dir)
+	if out, err := build.CombinedOutput(); err != nil {
+		return res, fmt.Errorf("building %s: %v\n%s", s.Dir, err, out)
+	}
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	runs := max(s.Runs, 1)
+	for i := 1; i <= runs; i++ {
+		var stdout, stderr bytes.Buffer
+		runCtx, cancel := context.WithTimeout(ctx, timeout)
+		cmd := exec.CommandContext(runCtx, bin)
+		cmd.Dir = build.Dir
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		timedOut := runCtx.Err() != nil
+		cancel()
+		if timedOut {
+			res.Problems = append(res.Problems, fmt.Sprintf("run %d: still running after %s", i, timeout))
+			continue
+		}
+
+		exitCode := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		} else if err != nil {
+			return res, fmt.Errorf("running %s: %w", s.Dir, err)
+		}
+
+		raced := bytes.Contains(stderr.Bytes(), raceWarning)
+		if raced {
+			res.Races++
+			if !s.WantRace {
+				res.Problems = append(res.Problems, fmt.Sprintf("run %d: unexpected data race:\n%s", i, firstRace(stderr.String())))
+			}
+		}
+		failed := exitCode != 0 && !(raced && exitCode == raceExitCode)
+		if failed != s.WantFail {
+			res.Problems = append(res.Problems, fmt.Sprintf("run %d: exit status %d, want failure %t\n%s", i, exitCode, s.WantFail, stderr.String()))
+		}
+		if s.Stdout != nil && !s.Stdout.Match(stdout.Bytes()) {
+			res.Problems = append(res.Problems, fmt.Sprintf("run %d: output %q does not match %q", i, stdout.String(), s.Stdout))
+		}
+		if s.Stderr != nil && !s.Stderr.Match(stderr.Bytes()) {
+			res.Problems = append(res.Problems, fmt.Sprintf("run %d: error output %q does not match %q", i, stderr.String(), s.Stderr))
+		}
+	}
+	if s.WantRace && res.Races == 0 {
+		res.Problems = append(res.Problems, fmt.Sprintf("no data race detected in %d runs", runs))
+	}
+	return res, nil
+}
+
+// firstRace trims the race detector's output down to the first report
+func firstRace(stderr string) string {
+	const sep = "=================="
+	parts := strings.SplitN(stderr, sep, 3)
+	if len(parts) < 3 {
+		return stderr
+	}
+	return sep + parts[1] + sep
+}
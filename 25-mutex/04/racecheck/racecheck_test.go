@@ -0,0 +1,105 @@
+package racecheck_test
+
+import (
+	"context"
+	"mutex_04/racecheck"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// root is the repository root, seen from this package's directory
+var root = filepath.Join("..", "..", "..")
+
+// scenarios is the regression suite. Any example in the tree can be checked by adding it here.
+var scenarios = []racecheck.Scenario{
+	{
+		// the unsynchronized x = x + 1 must be flagged by the race detector
+		Name:     "mutex/unsynchronized",
+		Dir:      "25-mutex/01",
+		Runs:     10,
+		WantRace: true,
+		Stdout:   regexp.MustCompile(`^final value of x \d+\n$`),
+	},
+	{
+		// with the mutex there is never a race and x always reaches 1000
+		Name:   "mutex/locked",
+		Dir:    "25-mutex/02",
+		Runs:   10,
+		Stdout: regexp.MustCompile(`^final value of x 1000\n$`),
+	},
+	{
+		Name:   "goroutines/interleaving",
+		Dir:    "21-goroutines/02",
+		Runs:   1,
+		Stdout: regexp.MustCompile(`^1 a 2 3 b 4 c 5 d e main terminated\n$`),
+	},
+	{
+		Name:   "channels/squares-and-cubes",
+		Dir:    "22-channels/09",
+		Runs:   5,
+		Stdout: regexp.MustCompile(`^Final output 1536\n$`),
+	},
+	{
+		Name:     "channels/deadlock",
+		Dir:      "22-channels/05",
+		Runs:     1,
+		WantFail: true,
+		NoRace:   true,
+		Stderr:   regexp.MustCompile(`all goroutines are asleep - deadlock!`),
+	},
+	{
+		Name:     "select/deadlock",
+		Dir:      "24-select/07",
+		Runs:     1,
+		WantFail: true,
+		NoRace:   true,
+		Stderr:   regexp.MustCompile(`goroutine 1 \[select \(no cases\)\]`),
+	},
+}
+
+// TestExamples checks every scenario; go test -run 'Examples/mutex/' checks only some of them.
+func TestExamples(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs every example")
+	}
+	for _, s := range scenarios {
+		t.Run(s.Name, func(t *testing.T) {
+			t.Parallel()
+			res, err := racecheck.Check(context.Background(), root, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range res.Problems {
+				t.Error(p)
+			}
+			t.Logf("%d/%d runs raced", res.Races, s.Runs)
+		})
+	}
+}
+
+// TestCheckFindsProblems makes sure a scenario that doesn't hold fails, so the suite can't pass by checking nothing.
+func TestCheckFindsProblems(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs an example")
+	}
+	for _, s := range []racecheck.Scenario{
+		// the locked example never races
+		{Name: "race", Dir: "25-mutex/02", Runs: 2, WantRace: true},
+		// and it prints 1000, not 999
+		{Name: "output", Dir: "25-mutex/02", Runs: 1, Stdout: regexp.MustCompile(`^final value of x 999\n$`)},
+		// and it doesn't crash
+		{Name: "fail", Dir: "25-mutex/02", Runs: 1, WantFail: true},
+	} {
+		t.Run(s.Name, func(t *testing.T) {
+			t.Parallel()
+			res, err := racecheck.Check(context.Background(), root, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Passed() {
+				t.Errorf("scenario %+v passed, want a problem", s)
+			}
+		})
+	}
+}
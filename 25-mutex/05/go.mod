@@ -0,0 +1,3 @@
+module mutex_05
+
+go 1.22.1
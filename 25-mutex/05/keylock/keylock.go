@@ -0,0 +1,278 @@
+package keylock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode is how a key is locked.
+type Mode int
+
+const (
+	// Write locks are exclusive, like sync.Mutex.Lock or sync.RWMutex.Lock.
+	Write Mode = iota
+	// Read locks can be held by any number of owners at once, like sync.RWMutex.RLock.
+	Read
+)
+
+func (m Mode) String() string {
+	if m == Read {
+		return "read"
+	}
+	return "write"
+}
+
+var (
+	// ErrTimeout is returned by TryLock when the key could not be locked in time.
+	ErrTimeout = errors.New("keylock: timed out waiting for lock")
+	// ErrNotHeld is returned by Unlock for a key the owner has not locked.
+	ErrNotHeld = errors.New("keylock: key not locked by this owner")
+)
+
+// A DeadlockError is returned instead of waiting forever when granting the lock
+// would close a cycle of owners each waiting for the next, such as two Goroutines
+// locking "account:42" and "account:43" in opposite orders.
+// The owner that gets the error should release the locks it holds and try again.
+type DeadlockError struct {
+	Key string
+	// Cycle lists the owners in the cycle, starting and ending with the one that got the error
+	Cycle []string
+}
+
+func (d *DeadlockError) Error() string {
+	return fmt.Sprintf("keylock: deadlock locking %q: %s", d.Key, strings.Join(d.Cycle, " -> "))
+}
+
+// entry is the lock for one key
+type entry struct {
+	writer  *Owner
+	readers map[*Owner]int
+	// upgraded is set while the writer holds the key by upgrading one of its read holds,
+	// which it gets back when it unlocks the write
+	upgraded bool
+	// the number of owners waiting for this key
+	waiters int
+	// closed and replaced every time the entry is released, to wake up the waiters
+	changed chan struct{}
+}
+
+// A Manager hands out locks on string keys. Locking one key never blocks a different key.
+// The zero value is not usable, create one with New.
+type Manager struct {
+	mu    sync.Mutex
+	locks map[string]*entry
+	// the key each owner is currently waiting for, the edges of the wait-for graph
+	waiting map[*Owner]string
+}
+
+// New creates an empty Manager.
+func New() *Manager {
+	return &Manager{
+		locks:   make(map[string]*entry),
+		waiting: make(map[*Owner]string),
+	}
+}
+
+// An Owner is whoever holds locks: usually one Goroutine, or one request.
+// Go doesn't let us tell Goroutines apart, so every lock is taken on behalf of an Owner.
+// An Owner must not be used by two Goroutines at the same time.
+type Owner struct {
+	m    *Manager
+	name string
+}
+
+// Owner creates a new owner. The name only appears in DeadlockErrors.
+func (m *Manager) Owner(name string) *Owner {
+	return &Owner{m: m, name: name}
+}
+
+// Lock locks key in the given mode, waiting until it is free.
+// It returns ctx.Err() if ctx is done first, or a *DeadlockError if waiting would never end.
+// Locking a key the owner already holds for reading again for reading is allowed, and must be unlocked as many times;
+// locking it for writing upgrades the lock once the owner is the only reader left.
+// The upgrade turns one of the owner's read holds into the write lock; the first Unlock releases the write
+// and gives that read hold back, so the owner still unlocks the key once for every time it locked it for reading.
+func (o *Owner) Lock(ctx context.Context, key string, mode Mode) error {
+	m := o.m
+	m.mu.Lock()
+	e := m.entry(key)
+	e.waiters++
+	defer func() {
+		e.waiters--
+		m.cleanup(key, e)
+		m.mu.Unlock()
+	}()
+
+	for {
+		if e.writer == o {
+			return fmt.Errorf("keylock: %s already holds %q for writing", o.name, key)
+		}
+		if e.free(o, mode) {
+			delete(m.waiting, o)
+			if mode == Write {
+				// the owner's other read holds stay in readers, where the wait-for graph can see them
+				if e.readers[o] > 0 {
+					e.release(o)
+					e.upgraded = true
+				}
+				e.writer = o
+			} else {
+				e.readers[o]++
+			}
+			return nil
+		}
+
+		m.waiting[o] = key
+		if cycle := m.cycle(o); cycle != nil {
+			delete(m.waiting, o)
+			return &DeadlockError{Key: key, Cycle: cycle}
+		}
+
+		changed := e.changed
+		m.mu.Unlock()
+		select {
+		case <-changed:
+			m.mu.Lock()
+		case <-ctx.Done():
+			m.mu.Lock()
+			delete(m.waiting, o)
+			return ctx.Err()
+		}
+	}
+}
+
+// TryLock is Lock with a time limit. A timeout of zero only succeeds if the key is free right now.
+// It returns ErrTimeout if the key could not be locked in time.
+func (o *Owner) TryLock(key string, mode Mode, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := o.Lock(ctx, key, mode)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return err
+}
+
+// Unlock releases one hold of key by the owner.
+func (o *Owner) Unlock(key string) error {
+	m := o.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.locks[key]
+	switch {
+	case !ok:
+		return ErrNotHeld
+	case e.writer == o:
+		e.writer = nil
+		if e.upgraded {
+			e.readers[o]++
+			e.upgraded = false
+		}
+	case e.readers[o] > 0:
+		e.release(o)
+	default:
+		return ErrNotHeld
+	}
+	close(e.changed)
+	e.changed = make(chan struct{})
+	m.cleanup(key, e)
+	return nil
+}
+
+// entry returns the lock for key, creating it if nobody has used it yet. The caller holds m.mu.
+func (m *Manager) entry(key string) *entry {
+	e, ok := m.locks[key]
+	if !ok {
+		e = &entry{readers: make(map[*Owner]int), changed: make(chan struct{})}
+		m.locks[key] = e
+	}
+	return e
+}
+
+// release drops one of o's read holds. The caller holds m.mu.
+func (e *entry) release(o *Owner) {
+	e.readers[o]--
+	if e.readers[o] == 0 {
+		delete(e.readers, o)
+	}
+}
+
+// cleanup forgets a key nobody holds or waits for, so the map doesn't grow forever. The caller holds m.mu.
+func (m *Manager) cleanup(key string, e *entry) {
+	if e.writer == nil && len(e.readers) == 0 && e.waiters == 0 {
+		delete(m.locks, key)
+	}
+}
+
+// free reports whether o can lock the entry in mode right now
+func (e *entry) free(o *Owner, mode Mode) bool {
+	if e.writer != nil {
+		return false
+	}
+	if mode == Read {
+		return true
+	}
+	// a writer needs the key to itself, except for its own read lock which it is upgrading
+	for r := range e.readers {
+		if r != o {
+			return false
+		}
+	}
+	return true
+}
+
+// holders returns the owners other than o that are stopping o from locking the entry
+func (e *entry) holders(o *Owner) []*Owner {
+	var hs []*Owner
+	if e.writer != nil && e.writer != o {
+		hs = append(hs, e.writer)
+	}
+	for r := range e.readers {
+		if r != o {
+			hs = append(hs, r)
+		}
+	}
+	return hs
+}
+
+// cycle follows the wait-for graph from start: start waits for the holders of its key,
+// who may be waiting for the holders of another key, and so on.
+// If the path leads back to start, nobody on it can ever make progress, and cycle returns the names along it.
+// The caller holds m.mu.
+func (m *Manager) cycle(start *Owner) []string {
+	visited := make(map[*Owner]bool)
+	var path []*Owner
+	var visit func(o *Owner) bool
+	visit = func(o *Owner) bool {
+		key, ok := m.waiting[o]
+		if !ok {
+			return false
+		}
+		path = append(path, o)
+		for _, h := range m.locks[key].holders(o) {
+			if h == start {
+				return true
+			}
+			if !visited[h] {
+				visited[h] = true
+				if visit(h) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if !visit(start) {
+		return nil
+	}
+	names := make([]string, 0, len(path)+1)
+	for _, o := range path {
+		names = append(names, o.name)
+	}
+	return append(names, start.name)
+}
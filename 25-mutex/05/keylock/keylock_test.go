@@ -0,0 +1,172 @@
+package keylock
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUpgradeKeepsReadHolds(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	a, b := m.Owner("a"), m.Owner("b")
+	for range 2 {
+		if err := a.Lock(ctx, "k", Read); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Lock(ctx, "k", Write); err != nil {
+		t.Fatalf("upgrading: %v", err)
+	}
+	if err := b.TryLock("k", Read, 0); !errors.Is(err, ErrTimeout) {
+		t.Errorf("reading a key locked for writing: got %v, want ErrTimeout", err)
+	}
+
+	// the first Unlock releases the write, the next two the read holds taken before it
+	for i := range 3 {
+		if err := a.Unlock("k"); err != nil {
+			t.Fatalf("unlock %d: %v", i+1, err)
+		}
+		if i < 2 {
+			if err := b.TryLock("k", Write, 0); !errors.Is(err, ErrTimeout) {
+				t.Errorf("after unlock %d the key is free for writing, want it still read locked: %v", i+1, err)
+			}
+		}
+	}
+	if err := a.Unlock("k"); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("unlocking once more than locked: got %v, want ErrNotHeld", err)
+	}
+	if err := b.TryLock("k", Write, 0); err != nil {
+		t.Errorf("locking the released key: %v", err)
+	}
+}
+
+func TestUpgradedReadHoldsAreInTheWaitForGraph(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	a, b := m.Owner("a"), m.Owner("b")
+	for _, mode := range []Mode{Read, Read, Write} {
+		if err := a.Lock(ctx, "x", mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Unlock("x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Lock(ctx, "y", Write); err != nil {
+		t.Fatal(err)
+	}
+
+	// b waits for the read holds a still has on x ...
+	waited := make(chan error, 1)
+	go func() { waited <- b.Lock(ctx, "x", Write) }()
+	for !m.isWaiting(b) {
+		select {
+		case err := <-waited:
+			t.Fatalf("b locked x while a holds it for reading, error %v", err)
+		case <-time.After(time.Millisecond):
+		}
+	}
+	// ... so a waiting for y, which b holds, would never end
+	var deadlock *DeadlockError
+	if err := a.Lock(ctx, "y", Write); !errors.As(err, &deadlock) {
+		t.Fatalf("locking y: got %v, want a *DeadlockError", err)
+	}
+
+	for range 2 {
+		if err := a.Unlock("x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := <-waited; err != nil {
+		t.Errorf("b locking x once a let go: %v", err)
+	}
+}
+
+func TestOppositeOrderDeadlock(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		// owner i locks keys[i] and then keys[i+1], the last one wrapping round to keys[0]
+		owners []string
+		keys   []string
+		// the error the last owner gets for its second key
+		cycle []string
+		msg   string
+	}{
+		{
+			name:   "two owners",
+			owners: []string{"a", "b"},
+			keys:   []string{"account:42", "account:43"},
+			cycle:  []string{"b", "a", "b"},
+			msg:    `keylock: deadlock locking "account:42": b -> a -> b`,
+		},
+		{
+			name:   "three owners",
+			owners: []string{"a", "b", "c"},
+			keys:   []string{"x", "y", "z"},
+			cycle:  []string{"c", "a", "b", "c"},
+			msg:    `keylock: deadlock locking "x": c -> a -> b -> c`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := New()
+			owners := make([]*Owner, len(tt.owners))
+			for i, name := range tt.owners {
+				owners[i] = m.Owner(name)
+				if err := owners[i].Lock(ctx, tt.keys[i], Write); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// every owner but the last waits for the next one's key
+			last := len(owners) - 1
+			waited := make([]chan error, last)
+			for i := range last {
+				waited[i] = make(chan error, 1)
+				go func() { waited[i] <- owners[i].Lock(ctx, tt.keys[i+1], Write) }()
+				for !m.isWaiting(owners[i]) {
+					time.Sleep(time.Millisecond)
+				}
+			}
+
+			// and the last one waiting for the first one's key would close the cycle
+			err := owners[last].Lock(ctx, tt.keys[0], Write)
+			var deadlock *DeadlockError
+			if !errors.As(err, &deadlock) {
+				t.Fatalf("got %v, want a *DeadlockError", err)
+			}
+			if deadlock.Key != tt.keys[0] || !slices.Equal(deadlock.Cycle, tt.cycle) {
+				t.Errorf("got a deadlock on %q with cycle %v, want %q with %v", deadlock.Key, deadlock.Cycle, tt.keys[0], tt.cycle)
+			}
+			if err.Error() != tt.msg {
+				t.Errorf("error %q, want %q", err, tt.msg)
+			}
+			if m.isWaiting(owners[last]) {
+				t.Error("the owner which got the error is still waiting")
+			}
+
+			// backing off lets the others through, one after the other
+			if err := owners[last].Unlock(tt.keys[last]); err != nil {
+				t.Fatal(err)
+			}
+			for i := last - 1; i >= 0; i-- {
+				if err := <-waited[i]; err != nil {
+					t.Errorf("%s locking %s: %v", tt.owners[i], tt.keys[i+1], err)
+				}
+				owners[i].Unlock(tt.keys[i+1])
+				owners[i].Unlock(tt.keys[i])
+			}
+		})
+	}
+}
+
+// isWaiting reports whether o is blocked in Lock
+func (m *Manager) isWaiting(o *Owner) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.waiting[o]
+	return ok
+}
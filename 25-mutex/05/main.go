@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mutex_05/keylock"
+	"sync"
+	"time"
+)
+
+// balances is the shared state; each account is protected by the lock on its own key
+var balances = map[string]int{"account:42": 100, "account:43": 100}
+
+// the map itself is read by every transfer, so it needs its own lock; the keyed locks protect the balances in it
+var balancesMu sync.Mutex
+
+// transfer locks both accounts, in the order given, and moves amount from one to the other.
+// Two transfers in opposite directions lock the same two keys in opposite orders,
+// which with two plain mutexes could leave both Goroutines waiting for each other forever.
+func transfer(o *keylock.Owner, from, to string, amount int) error {
+	ctx := context.Background()
+	if err := o.Lock(ctx, from, keylock.Write); err != nil {
+		return err
+	}
+	defer o.Unlock(from)
+
+	// give the other transfer time to lock its first account
+	time.Sleep(100 * time.Millisecond)
+
+	if err := o.Lock(ctx, to, keylock.Write); err != nil {
+		return err
+	}
+	defer o.Unlock(to)
+
+	balancesMu.Lock()
+	balances[from] -= amount
+	balances[to] += amount
+	balancesMu.Unlock()
+	return nil
+}
+
+func main() {
+	m := keylock.New()
+
+	var wg sync.WaitGroup
+	for _, t := range []struct {
+		name     string
+		from, to string
+	}{
+		{"alice", "account:42", "account:43"},
+		{"bob", "account:43", "account:42"},
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o := m.Owner(t.name)
+			for {
+				err := transfer(o, t.from, t.to, 10)
+				var deadlock *keylock.DeadlockError
+				if errors.As(err, &deadlock) {
+					// the deferred unlocks in transfer have released the first account, so the other transfer can finish
+					fmt.Println(t.name, "backs off:", err)
+					time.Sleep(50 * time.Millisecond)
+					continue
+				}
+				fmt.Println(t.name, "transferred 10 from", t.from, "to", t.to)
+				return
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Println("balances", balances["account:42"], balances["account:43"])
+
+	// locking different keys never blocks
+	a, b := m.Owner("a"), m.Owner("b")
+	a.Lock(context.Background(), "account:42", keylock.Write)
+	fmt.Println("b locks account:43 while a holds account:42:", b.TryLock("account:43", keylock.Write, 0))
+	fmt.Println("b locks account:42 while a holds it:", b.TryLock("account:42", keylock.Write, 50*time.Millisecond))
+	a.Unlock("account:42")
+
+	// any number of readers share a key
+	a.Lock(context.Background(), "report", keylock.Read)
+	fmt.Println("b reads alongside a:", b.TryLock("report", keylock.Read, 0))
+}
+
+// bob backs off: keylock: deadlock locking "account:42": bob -> alice -> bob
+// (or alice, whichever of the two asks for its second account last)
+// alice transferred 10 from account:42 to account:43
+// bob transferred 10 from account:43 to account:42
+// balances 100 100
+// b locks account:43 while a holds account:42: <nil>
+// b locks account:42 while a holds it: keylock: timed out waiting for lock
+// b reads alongside a: <nil>
@@ -0,0 +1,202 @@
+package lockprof
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// Stats is what a Profile knows about one call site of Lock on one mutex.
+type Stats struct {
+	// Mutex is the name the mutex was created with
+	Mutex string
+	// Site is where Lock was called from, as "function file:line"
+	Site string
+	// Acquisitions is the number of times the lock was taken at this site
+	Acquisitions int64
+	// Contended is how many of those found the lock already held and had to wait
+	Contended int64
+	TotalWait time.Duration
+	MaxWait   time.Duration
+	TotalHold time.Duration
+	MaxHold   time.Duration
+}
+
+type siteKey struct {
+	mutex string
+	pc    uintptr
+}
+
+// site is the running statistics of one call site.
+// Every field is updated atomically, so recording never makes two mutexes wait for each other,
+// which would add contention of the profiler's own to what it measures.
+type site struct {
+	name         string
+	acquisitions atomic.Int64
+	contended    atomic.Int64
+	// the durations are in nanoseconds
+	totalWait atomic.Int64
+	maxWait   atomic.Int64
+	totalHold atomic.Int64
+	maxHold   atomic.Int64
+}
+
+// A Profile collects the statistics of every Mutex created from it.
+type Profile struct {
+	// sites maps a siteKey to its *site. A sync.Map suits it: every key is stored once and then only read,
+	// and reading doesn't lock.
+	sites sync.Map
+}
+
+// NewProfile creates an empty Profile.
+func NewProfile() *Profile {
+	return &Profile{}
+}
+
+// A Mutex is a sync.Mutex that records, for every place it is locked from,
+// how long callers waited to get it and how long they held it.
+// It is used exactly like sync.Mutex, so it can replace the one guarding x in 25-mutex/02.
+type Mutex struct {
+	name    string
+	profile *Profile
+	mu      sync.Mutex
+
+	// set by Lock and read by Unlock; only the Goroutine holding mu touches them
+	site     uintptr
+	waited   time.Duration
+	locked   time.Time
+	contends bool
+}
+
+// NewMutex creates a Mutex reporting to p under name.
+func (p *Profile) NewMutex(name string) *Mutex {
+	return &Mutex{name: name, profile: p}
+}
+
+// Lock locks m, recording the caller as the call site.
+func (m *Mutex) Lock() {
+	var pc [1]uintptr
+	// skip runtime.Callers and Lock itself
+	runtime.Callers(2, pc[:])
+
+	start := time.Now()
+	contended := false
+	if !m.mu.TryLock() {
+		// someone else holds the lock, this is the contention we want to measure
+		contended = true
+		m.mu.Lock()
+	}
+	now := time.Now()
+
+	m.site = pc[0]
+	m.contends = contended
+	m.waited = now.Sub(start)
+	m.locked = now
+}
+
+// Unlock unlocks m and records how long the lock was held.
+func (m *Mutex) Unlock() {
+	held := time.Since(m.locked)
+	site, waited, contended := m.site, m.waited, m.contends
+	m.mu.Unlock()
+	m.profile.record(m.name, site, waited, held, contended)
+}
+
+func (p *Profile) record(mutex string, pc uintptr, waited, held time.Duration, contended bool) {
+	key := siteKey{mutex, pc}
+	v, ok := p.sites.Load(key)
+	if !ok {
+		// two Goroutines may get here for a new site at once, LoadOrStore keeps only one of their sites
+		v, _ = p.sites.LoadOrStore(key, &site{name: siteName(pc)})
+	}
+	s := v.(*site)
+	s.acquisitions.Add(1)
+	if contended {
+		s.contended.Add(1)
+	}
+	s.totalWait.Add(int64(waited))
+	storeMax(&s.maxWait, int64(waited))
+	s.totalHold.Add(int64(held))
+	storeMax(&s.maxHold, int64(held))
+}
+
+// storeMax sets m to v if v is larger
+func storeMax(m *atomic.Int64, v int64) {
+	for {
+		old := m.Load()
+		if v <= old || m.CompareAndSwap(old, v) {
+			return
+		}
+	}
+}
+
+// siteName turns a return address into "main.increment main.go:21"
+func siteName(pc uintptr) string {
+	frames := runtime.CallersFrames([]uintptr{pc})
+	f, _ := frames.Next()
+	if f.Function == "" {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s %s:%d", f.Function, filepath.Base(f.File), f.Line)
+}
+
+// Report returns the statistics of every call site, the one callers spent the longest waiting at first.
+// Locks released while it runs may be only partly included.
+func (p *Profile) Report() []Stats {
+	var stats []Stats
+	p.sites.Range(func(k, v any) bool {
+		s := v.(*site)
+		acquisitions := s.acquisitions.Load()
+		if acquisitions == 0 {
+			// stored by a record that hasn't counted its lock yet
+			return true
+		}
+		stats = append(stats, Stats{
+			Mutex:        k.(siteKey).mutex,
+			Site:         s.name,
+			Acquisitions: acquisitions,
+			Contended:    s.contended.Load(),
+			TotalWait:    time.Duration(s.totalWait.Load()),
+			MaxWait:      time.Duration(s.maxWait.Load()),
+			TotalHold:    time.Duration(s.totalHold.Load()),
+			MaxHold:      time.Duration(s.maxHold.Load()),
+		})
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalWait != stats[j].TotalWait {
+			return stats[i].TotalWait > stats[j].TotalWait
+		}
+		return stats[i].Site < stats[j].Site
+	})
+	return stats
+}
+
+// WriteReport writes the report as a table.
+func (p *Profile) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "mutex\tsite\tlocks\tcontended\ttotal wait\tmax wait\ttotal hold\tmax hold")
+	for _, s := range p.Report() {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.0f%%\t%s\t%s\t%s\t%s\n",
+			s.Mutex, s.Site, s.Acquisitions, 100*float64(s.Contended)/float64(s.Acquisitions),
+			round(s.TotalWait), round(s.MaxWait), round(s.TotalHold), round(s.MaxHold))
+	}
+	return tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	default:
+		return d
+	}
+}
@@ -0,0 +1,49 @@
+package lockprof
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProfileCountsEveryLock(t *testing.T) {
+	p := NewProfile()
+	a, b := p.NewMutex("a"), p.NewMutex("b")
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				a.Lock()
+				a.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				b.Lock()
+				time.Sleep(time.Microsecond)
+				b.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := p.Report()
+	if len(stats) != 2 {
+		t.Fatalf("got %d sites, want one for each mutex: %+v", len(stats), stats)
+	}
+	for _, s := range stats {
+		if s.Acquisitions != 5000 {
+			t.Errorf("%s was locked %d times at %s, want 5000", s.Mutex, s.Acquisitions, s.Site)
+		}
+		if s.Contended > s.Acquisitions || s.MaxWait > s.TotalWait || s.MaxHold > s.TotalHold {
+			t.Errorf("%s: the counts don't add up: %+v", s.Mutex, s)
+		}
+	}
+	// b sleeps while it holds the lock, so it is the one callers waited longest for
+	if stats[0].Mutex != "b" || stats[0].MaxHold < time.Microsecond {
+		t.Errorf("the report starts with %+v, want mutex b held for at least a microsecond", stats[0])
+	}
+}
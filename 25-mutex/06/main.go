@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"mutex_06/lockprof"
+	"os"
+	"sync"
+	"time"
+)
+
+// shared variable that will be incremented
+var x = 0
+
+// the increment function from 25-mutex/02, with a profiled mutex in place of the sync.Mutex
+func increment(wg *sync.WaitGroup, m *lockprof.Mutex) {
+	m.Lock()
+	x = x + 1
+	m.Unlock()
+	wg.Done()
+}
+
+// incrementSlowly does the same, but does some slow work while holding the lock
+// which makes every other Goroutine queue up behind it
+func incrementSlowly(wg *sync.WaitGroup, m *lockprof.Mutex) {
+	m.Lock()
+	time.Sleep(time.Millisecond)
+	x = x + 1
+	m.Unlock()
+	wg.Done()
+}
+
+func main() {
+	p := lockprof.NewProfile()
+	m := p.NewMutex("x")
+
+	var w sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		w.Add(2)
+		go increment(&w, m)
+		if i%10 == 0 {
+			go incrementSlowly(&w, m)
+		} else {
+			go increment(&w, m)
+		}
+	}
+	w.Wait()
+	fmt.Println("final value of x", x)
+	fmt.Println()
+
+	// the report is sorted by total wait: the call site at the top is the one to look at first
+	p.WriteReport(os.Stdout)
+}
+
+// final value of x 2000
+//
+// mutex  site                             locks  contended  total wait  max wait   total hold  max hold
+// x      main.increment main.go:16        1900   99%        1m42.302s   109.002ms  252.114µs   4.157µs
+// x      main.incrementSlowly main.go:25  100    99%        5.37s       107.924ms  109.216ms   2.072ms
@@ -0,0 +1,277 @@
+package kv
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+)
+
+// the number of shards when Options.Shards is not set
+const defaultShards = 32
+
+// Options configures a Store.
+type Options struct {
+	// Shards is the number of independently locked parts the keys are spread over, 32 if zero.
+	// More shards means fewer Goroutines competing for the same lock.
+	Shards int
+	// WAL is the path of the write-ahead log. The store is in memory only if it is empty.
+	WAL string
+	// Sync makes every write wait until the log is flushed to disk, trading speed for not losing the last writes in a crash.
+	Sync bool
+	// Now returns the current time, time.Now if nil. It is used to expire keys.
+	Now func() time.Time
+}
+
+type item struct {
+	value string
+	// zero for keys that never expire
+	expires time.Time
+}
+
+func (it item) expired(now time.Time) bool {
+	return !it.expires.IsZero() && !now.Before(it.expires)
+}
+
+// shard is the 25-mutex pattern applied to a map: every access to items happens with mu held.
+// It is a RWMutex because reads are far more common than writes, and any number of readers can share it.
+type shard struct {
+	mu    sync.RWMutex
+	items map[string]item
+}
+
+// A Store is a concurrent key/value store. All its methods are safe to call from any number of Goroutines.
+type Store struct {
+	shards []*shard
+	wal    *wal
+	now    func() time.Time
+}
+
+// An Entry is one key and its value in a Snapshot.
+type Entry struct {
+	Key   string
+	Value string
+	// Expires is zero if the key never expires
+	Expires time.Time
+}
+
+// Open creates a Store. If opts.WAL is set, the log is replayed to restore the data written before, and every write is appended to it.
+func Open(opts Options) (*Store, error) {
+	n := opts.Shards
+	if n <= 0 {
+		n = defaultShards
+	}
+	s := &Store{shards: make([]*shard, n), now: opts.Now}
+	if s.now == nil {
+		s.now = time.Now
+	}
+	for i := range s.shards {
+		s.shards[i] = &shard{items: make(map[string]item)}
+	}
+	if opts.WAL != "" {
+		w, err := openWAL(opts.WAL, opts.Sync, s.replay)
+		if err != nil {
+			return nil, err
+		}
+		s.wal = w
+	}
+	return s, nil
+}
+
+// Close closes the write-ahead log.
+func (s *Store) Close() error {
+	if s.wal == nil {
+		return nil
+	}
+	return s.wal.close()
+}
+
+// shardFor picks the shard a key lives in by hashing it
+func (s *Store) shardFor(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+// Get returns the value of key and whether it was found.
+func (s *Store) Get(key string) (string, bool) {
+	sh := s.shardFor(key)
+	sh.mu.RLock()
+	it, ok := sh.items[key]
+	sh.mu.RUnlock()
+	if !ok || it.expired(s.now()) {
+		return "", false
+	}
+	return it.value, true
+}
+
+// Set sets key to value, with no expiry.
+func (s *Store) Set(key, value string) error {
+	return s.SetTTL(key, value, 0)
+}
+
+// SetTTL sets key to value for ttl, after which it disappears. A ttl of zero means forever.
+// A negative ttl is an error, it is most likely a time subtracted the wrong way round.
+func (s *Store) SetTTL(key, value string, ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("kv: setting %q: negative ttl %s", key, ttl)
+	}
+	it := item{value: value}
+	if ttl > 0 {
+		it.expires = s.now().Add(ttl)
+	}
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	// the log is written while the shard is locked, so it records writes to a key in the order they happened
+	if err := s.log(record{Op: opSet, Key: key, Value: value, Expires: expiresAt(it.expires)}); err != nil {
+		return err
+	}
+	sh.items[key] = it
+	return nil
+}
+
+// Delete removes key, reporting whether it was there.
+func (s *Store) Delete(key string) (bool, error) {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	it, ok := sh.items[key]
+	if !ok || it.expired(s.now()) {
+		delete(sh.items, key)
+		return false, nil
+	}
+	if err := s.log(record{Op: opDelete, Key: key}); err != nil {
+		return false, err
+	}
+	delete(sh.items, key)
+	return true, nil
+}
+
+// CompareAndSwap sets key to new only if its current value is old, and reports whether it did.
+// An old value of "" matches a key that does not exist, so CompareAndSwap can also be used to create a key only once.
+// The key keeps its expiry time.
+func (s *Store) CompareAndSwap(key, old, new string) (bool, error) {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	it, ok := sh.items[key]
+	if ok && it.expired(s.now()) {
+		ok = false
+		it = item{}
+	}
+	if (ok && it.value != old) || (!ok && old != "") {
+		return false, nil
+	}
+	it.value = new
+	if err := s.log(record{Op: opSet, Key: key, Value: new, Expires: expiresAt(it.expires)}); err != nil {
+		return false, err
+	}
+	sh.items[key] = it
+	return true, nil
+}
+
+// Len returns the number of keys which have not expired.
+func (s *Store) Len() int {
+	now := s.now()
+	n := 0
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for _, it := range sh.items {
+			if !it.expired(now) {
+				n++
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	return n
+}
+
+// Snapshot returns every key which has not expired, sorted by key.
+// It read locks all shards at once, so it is a consistent picture of the store at one moment:
+// a write that happens while it is taken is either entirely in it or not at all.
+func (s *Store) Snapshot() []Entry {
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+	}
+	now := s.now()
+	var entries []Entry
+	for _, sh := range s.shards {
+		for k, it := range sh.items {
+			if !it.expired(now) {
+				entries = append(entries, Entry{Key: k, Value: it.value, Expires: it.expires})
+			}
+		}
+	}
+	for _, sh := range s.shards {
+		sh.mu.RUnlock()
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// Range calls fn for every entry of a Snapshot, stopping early if fn returns false.
+// Since it iterates over a copy, fn is free to call other methods of the store.
+func (s *Store) Range(fn func(e Entry) bool) {
+	for _, e := range s.Snapshot() {
+		if !fn(e) {
+			return
+		}
+	}
+}
+
+// Expire removes every expired key and returns how many there were.
+// Expired keys are already invisible to Get, this only frees their memory; see StartJanitor.
+func (s *Store) Expire() int {
+	now := s.now()
+	n := 0
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for k, it := range sh.items {
+			if it.expired(now) {
+				delete(sh.items, k)
+				n++
+			}
+		}
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// StartJanitor calls Expire every interval in a new Goroutine until the returned stop function is called.
+func (s *Store) StartJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Expire()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func (s *Store) log(r record) error {
+	if s.wal == nil {
+		return nil
+	}
+	return s.wal.append(r)
+}
+
+// replay applies a record read back from the log while opening the store
+func (s *Store) replay(r record) {
+	sh := s.shardFor(r.Key)
+	switch r.Op {
+	case opSet:
+		sh.items[r.Key] = item{value: r.Value, expires: r.expiry()}
+	case opDelete:
+		delete(sh.items, r.Key)
+	}
+}
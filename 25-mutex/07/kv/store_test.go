@@ -0,0 +1,242 @@
+package kv
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for Options.Now which only moves when told to
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func open(t *testing.T, opts Options) *Store {
+	t.Helper()
+	s, err := Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func set(t *testing.T, s *Store, key, value string) {
+	t.Helper()
+	if err := s.Set(key, value); err != nil {
+		t.Fatalf("setting %s: %v", key, err)
+	}
+}
+
+// want checks the value of every key, "" meaning the key must be missing
+func want(t *testing.T, s *Store, values map[string]string) {
+	t.Helper()
+	for k, v := range values {
+		got, ok := s.Get(k)
+		if v == "" && ok {
+			t.Errorf("%s is %q, want it missing", k, got)
+		}
+		if v != "" && got != v {
+			t.Errorf("%s is %q, found %t, want %q", k, got, ok, v)
+		}
+	}
+}
+
+func TestReplayAfterTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.log")
+	s := open(t, Options{WAL: path})
+	set(t, s, "a", "1")
+	set(t, s, "b", "2")
+	if _, err := s.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// the program stopped half way through writing a record
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"op":"set","key":"c","val`)
+	f.Close()
+
+	s = open(t, Options{WAL: path})
+	want(t, s, map[string]string{"a": "", "b": "2", "c": ""})
+	// the torn record is cut off, so the next one starts on a line of its own
+	set(t, s, "d", "4")
+	s.Close()
+
+	s = open(t, Options{WAL: path})
+	defer s.Close()
+	want(t, s, map[string]string{"b": "2", "d": "4"})
+}
+
+// tornWriter writes the first n bytes it is given to w and then fails, like a full disk
+type tornWriter struct {
+	w *os.File
+	n int
+}
+
+func (t *tornWriter) Write(p []byte) (int, error) {
+	n, _ := t.w.Write(p[:min(t.n, len(p))])
+	return n, errors.New("no space left on device")
+}
+
+func TestFailedAppendIsUndone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.log")
+	s := open(t, Options{WAL: path})
+	set(t, s, "a", "1")
+
+	s.wal.w = bufio.NewWriter(&tornWriter{w: s.wal.f, n: 10})
+	if err := s.Set("b", "2"); err == nil {
+		t.Fatal("setting b while the disk is full succeeded")
+	}
+	// the failed write is not sticky, the store carries on once the disk has room
+	set(t, s, "c", "3")
+	want(t, s, map[string]string{"a": "1", "b": "", "c": "3"})
+	s.Close()
+
+	s = open(t, Options{WAL: path})
+	defer s.Close()
+	want(t, s, map[string]string{"a": "1", "b": "", "c": "3"})
+}
+
+func TestBrokenLogRefusesWrites(t *testing.T) {
+	s := open(t, Options{WAL: filepath.Join(t.TempDir(), "kv.log")})
+	// closing the already closed log fails, which doesn't matter here
+	defer s.Close()
+	set(t, s, "a", "1")
+
+	// with the file gone, the failed write can't be cut back out of it either
+	s.wal.f.Close()
+	if err := s.Set("b", "2"); err == nil {
+		t.Fatal("setting b with the log closed succeeded")
+	}
+	err := s.Set("c", "3")
+	if err == nil || !strings.Contains(err.Error(), "is broken") {
+		t.Errorf("setting c after the log broke: got %v, want it to say the log is broken", err)
+	}
+	want(t, s, map[string]string{"a": "1", "b": "", "c": ""})
+}
+
+// TestConcurrentSetGet is meant to be run with the race detector as well:
+//
+//	go test -race ./kv
+func TestConcurrentSetGet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.log")
+	s := open(t, Options{WAL: path, Shards: 4})
+	goroutines, keys := 2000, 20
+	if testing.Short() {
+		goroutines = 100
+	}
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range keys {
+				key := strconv.Itoa(g) + ":" + strconv.Itoa(i)
+				if err := s.Set(key, key); err != nil {
+					t.Error(err)
+					return
+				}
+				if v, ok := s.Get(key); v != key {
+					t.Errorf("%s read back as %q, found %t", key, v, ok)
+				}
+			}
+			// and everyone fights over one key
+			for {
+				old, _ := s.Get("counter")
+				n, _ := strconv.Atoi(old)
+				ok, err := s.CompareAndSwap("counter", old, strconv.Itoa(n+1))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if ok {
+					break
+				}
+			}
+			// snapshots copy every key, so only some of the Goroutines take one
+			if g%100 == 0 {
+				s.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+
+	check := func(s *Store) {
+		t.Helper()
+		if n := s.Len(); n != goroutines*keys+1 {
+			t.Errorf("%d keys, want %d", n, goroutines*keys+1)
+		}
+		want(t, s, map[string]string{"counter": strconv.Itoa(goroutines), "7:13": "7:13"})
+	}
+	check(s)
+	s.Close()
+	s = open(t, Options{WAL: path})
+	defer s.Close()
+	check(s)
+}
+
+func TestTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	path := filepath.Join(t.TempDir(), "kv.log")
+	s := open(t, Options{WAL: path, Now: clock.Now})
+	if err := s.SetTTL("session", "abc", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetTTL("cache", "data", 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	set(t, s, "greeting", "hello")
+	if err := s.SetTTL("bad", "x", -time.Second); err == nil {
+		t.Error("a negative ttl was accepted")
+	}
+
+	clock.Advance(59 * time.Second)
+	want(t, s, map[string]string{"session": "abc", "greeting": "hello", "bad": ""})
+
+	clock.Advance(time.Second)
+	want(t, s, map[string]string{"session": "", "greeting": "hello"})
+	if n := s.Len(); n != 2 {
+		t.Errorf("%d keys once the session expired, want 2", n)
+	}
+	// a key that expired can be created again with CompareAndSwap
+	if ok, err := s.CompareAndSwap("session", "", "def"); !ok || err != nil {
+		t.Errorf("recreating the expired session: %t, %v", ok, err)
+	}
+	if n := s.Expire(); n != 0 {
+		t.Errorf("Expire removed %d keys, want 0: the session was recreated", n)
+	}
+	s.Close()
+
+	// the expiry time is kept in the log, and the recreated session has none
+	clock.Advance(30 * time.Second)
+	s = open(t, Options{WAL: path, Now: clock.Now})
+	defer s.Close()
+	want(t, s, map[string]string{"session": "def", "cache": "data"})
+	clock.Advance(30 * time.Second)
+	want(t, s, map[string]string{"session": "def", "cache": ""})
+	if n := s.Expire(); n != 1 {
+		t.Errorf("Expire removed %d keys, want the cache", n)
+	}
+}
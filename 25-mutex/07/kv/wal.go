@@ -0,0 +1,246 @@
+package kv
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	opSet    = "set"
+	opDelete = "del"
+)
+
+// record is one line of the write-ahead log
+type record struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	// Expires is in Unix nanoseconds, zero if the key never expires
+	Expires int64 `json:"expires,omitempty"`
+}
+
+// expiresAt converts an expiry time to the form stored in a record
+func expiresAt(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// expiry converts a record's Expires back to a time
+func (r record) expiry() time.Time {
+	if r.Expires == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, r.Expires)
+}
+
+// wal is an append-only log of every write, one JSON record per line.
+// Replaying it from the start rebuilds the store as it was when the last record was written.
+type wal struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	w    *bufio.Writer
+	sync bool
+	// size is the length of the log up to the end of its last complete record
+	size int64
+	// err is set once a failed append could not be undone, after which the log can't be trusted and refuses every append
+	err error
+}
+
+// openWAL replays the log at path through apply and opens it for appending, creating it if needed
+func openWAL(path string, sync bool, apply func(record)) (*wal, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	good, err := replay(f, apply)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("replaying %s: %w", path, err)
+	}
+	// drop a record that was only half written when the program stopped
+	if err := f.Truncate(good); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(good, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &wal{path: path, f: f, w: bufio.NewWriter(f), sync: sync, size: good}, nil
+}
+
+// replay applies every complete record and returns the offset just past the last one
+func replay(f *os.File, apply func(record)) (int64, error) {
+	r := bufio.NewReader(f)
+	var offset int64
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// anything after the last newline is a torn write
+			return offset, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		var rec record
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		apply(rec)
+		offset += int64(len(b))
+	}
+}
+
+func (l *wal) append(r record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	l.w.Write(b)
+	l.w.WriteByte('\n')
+	err = l.w.Flush()
+	if err == nil && l.sync {
+		err = l.f.Sync()
+	}
+	if err != nil {
+		l.undo(err)
+		return err
+	}
+	l.size += int64(len(b)) + 1
+	return nil
+}
+
+// undo removes whatever part of a failed append reached the file,
+// so the next record doesn't follow a torn one, and resets the writer,
+// whose error would otherwise make every later append fail too.
+// If the file can't be cut back, the log is marked as broken.
+func (l *wal) undo(cause error) {
+	err := l.f.Truncate(l.size)
+	if err == nil {
+		_, err = l.f.Seek(l.size, io.SeekStart)
+	}
+	if err != nil {
+		l.err = fmt.Errorf("kv: write-ahead log %s is broken, a failed write (%v) could not be undone: %w", l.path, cause, err)
+		return
+	}
+	l.w.Reset(l.f)
+}
+
+func (l *wal) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.w.Flush(); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
+
+// rewrite replaces the log with one set record per entry, which is all that is needed to rebuild the store.
+// The new log is written next to the old one and renamed over it, so a crash part way leaves the old log intact.
+// The rename itself is only durable once the directory has been synced too;
+// until then a crash can bring back the old log, which still rebuilds the same store.
+func (l *wal) rewrite(entries []Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(record{Op: opSet, Key: e.Key, Value: e.Value, Expires: expiresAt(e.Expires)}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		tmp.Close()
+		return err
+	}
+	l.swap(tmp, size)
+	// the new log is in place either way, syncing the directory only makes it survive a crash
+	return syncDir(filepath.Dir(l.path))
+}
+
+// swap makes f, which holds size bytes of complete records, the file the log appends to. The caller holds l.mu.
+func (l *wal) swap(f *os.File, size int64) {
+	l.w.Flush()
+	l.f.Close()
+	l.f = f
+	l.w = bufio.NewWriter(f)
+	l.size = size
+	// the new log holds only complete records, so a broken old one no longer matters
+	l.err = nil
+}
+
+// syncDir flushes dir's entries to disk, making a file created or renamed in it durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// Compact shrinks the write-ahead log down to the current contents of the store,
+// dropping overwritten values, deleted and expired keys.
+// Writes wait until it is done.
+func (s *Store) Compact() error {
+	if s.wal == nil {
+		return nil
+	}
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+	}
+	defer func() {
+		for _, sh := range s.shards {
+			sh.mu.Unlock()
+		}
+	}()
+	now := s.now()
+	var entries []Entry
+	for _, sh := range s.shards {
+		for k, it := range sh.items {
+			if !it.expired(now) {
+				entries = append(entries, Entry{Key: k, Value: it.value, Expires: it.expires})
+			}
+		}
+	}
+	return s.wal.rewrite(entries)
+}
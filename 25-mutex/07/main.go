@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"mutex_07/kv"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const usage = `usage: go run . [-data file] command [arguments]
+
+commands:
+  get key
+  set key value [ttl]    ttl is a duration such as 30s, the key never expires without it
+  del key
+  cas key old new        set key to new only if it is old, "" matches a missing key
+  list
+  compact                shrink the data file down to the current keys
+  stress [goroutines]    hammer an in-memory store from many Goroutines and check the result
+`
+
+func main() {
+	data := flag.String("data", "kv.log", "the write-ahead log the store is kept in")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if err := run(*data, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+var errUsage = errors.New(usage)
+
+func run(data string, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	if args[0] == "stress" {
+		n := 5000
+		if len(args) > 1 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil {
+				return err
+			}
+		}
+		return stress(n)
+	}
+
+	s, err := kv.Open(kv.Options{WAL: data, Sync: true})
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	switch {
+	case args[0] == "get" && len(args) == 2:
+		v, ok := s.Get(args[1])
+		if !ok {
+			return fmt.Errorf("%s: not found", args[1])
+		}
+		fmt.Println(v)
+	case args[0] == "set" && len(args) == 3:
+		return s.Set(args[1], args[2])
+	case args[0] == "set" && len(args) == 4:
+		ttl, err := time.ParseDuration(args[3])
+		if err != nil {
+			return err
+		}
+		return s.SetTTL(args[1], args[2], ttl)
+	case args[0] == "del" && len(args) == 2:
+		ok, err := s.Delete(args[1])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%s: not found", args[1])
+		}
+	case args[0] == "cas" && len(args) == 4:
+		ok, err := s.CompareAndSwap(args[1], args[2], args[3])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%s: value is not %q", args[1], args[2])
+		}
+	case args[0] == "list" && len(args) == 1:
+		for _, e := range s.Snapshot() {
+			if e.Expires.IsZero() {
+				fmt.Printf("%s=%s\n", e.Key, e.Value)
+			} else {
+				fmt.Printf("%s=%s (expires in %s)\n", e.Key, e.Value, time.Until(e.Expires).Round(time.Second))
+			}
+		}
+	case args[0] == "compact" && len(args) == 1:
+		return s.Compact()
+	default:
+		return errUsage
+	}
+	return nil
+}
+
+// stress runs n Goroutines against one store. Each of them
+//   - sets a key of its own and reads it back,
+//   - increments a shared counter with a CompareAndSwap loop, the x = x + 1 of 25-mutex done without a lock of our own,
+//   - takes a snapshot while the others are writing.
+//
+// At the end every key must be there and the counter must be exactly n.
+func stress(n int) error {
+	s, err := kv.Open(kv.Options{})
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := "key:" + strconv.Itoa(i)
+			s.Set(key, strconv.Itoa(i))
+			if v, ok := s.Get(key); !ok || v != strconv.Itoa(i) {
+				errs <- fmt.Errorf("%s: read back %q, %t", key, v, ok)
+				return
+			}
+			for {
+				old, _ := s.Get("counter")
+				c, _ := strconv.Atoi(old)
+				if ok, _ := s.CompareAndSwap("counter", old, strconv.Itoa(c+1)); ok {
+					break
+				}
+			}
+			if i%100 == 0 {
+				s.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		return err
+	}
+
+	counter, _ := s.Get("counter")
+	fmt.Printf("%d goroutines, counter %s, %d keys, took %s\n", n, counter, s.Len(), time.Since(start).Round(time.Millisecond))
+	if counter != strconv.Itoa(n) || s.Len() != n+1 {
+		return fmt.Errorf("want counter %d and %d keys", n, n+1)
+	}
+	return nil
+}
+
+// go run . set greeting hello
+// go run . set session abc 1m
+// go run . list
+// greeting=hello
+// session=abc (expires in 1m0s)
+// go run . cas greeting hello hi
+// go run . get greeting
+// hi
+// go run . stress 10000
+// 10000 goroutines, counter 10000, 10001 keys, took 499ms
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"oop/employee"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// flags returns the flag set of a command, which reports its own errors and leaves the usage line to main
+func flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {}
+	return fs
+}
+
+// parse parses args into fs and checks that want positional arguments are left, or at least want if atLeast is set
+func parse(fs *flag.FlagSet, args []string, want int, atLeast bool) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if n := fs.NArg(); n < want || n > want && !atLeast {
+		return errUsage
+	}
+	return nil
+}
+
+func add(c *cli, args []string) error {
+	fs := flags("add")
+	first := fs.String("first", "", "the first name")
+	last := fs.String("last", "", "the last name")
+	leave := fs.String("leave", "0", "the days of annual leave a year, such as 30 or 27.5")
+	taken := fs.String("taken", "0", "the days of annual leave already taken")
+	start := fs.String("start", "", "the day the employee joined, such as 2024-01-15")
+	region := fs.String("region", "", "the region whose public holidays the employee has")
+	salary := fs.String("salary", "", "the yearly salary, such as 52000")
+	hourly := fs.String("hourly", "", "the pay for an hour, such as 31.50, for employees who are paid by the hour")
+	if err := parse(fs, args, 0, false); err != nil {
+		return err
+	}
+	total, err := employee.ParseAmount(*leave)
+	if err != nil {
+		return fmt.Errorf("-leave: %w", err)
+	}
+	took, err := employee.ParseAmount(*taken)
+	if err != nil {
+		return fmt.Errorf("-taken: %w", err)
+	}
+	opts := []employee.Option{employee.WithLeave(total, took), employee.WithRegion(*region)}
+	if *start != "" {
+		t, err := time.Parse(time.DateOnly, *start)
+		if err != nil {
+			return errors.New("-start: must be a date such as 2006-01-02")
+		}
+		opts = append(opts, employee.WithStartDate(t))
+	}
+	switch {
+	case *salary != "" && *hourly != "":
+		return errors.New("an employee is paid either a -salary or -hourly, not both")
+	case *salary != "":
+		rate, err := employee.ParseCents(*salary)
+		if err != nil {
+			return fmt.Errorf("-salary: %w", err)
+		}
+		opts = append(opts, employee.WithPay(employee.Pay{Basis: employee.Salaried, Rate: rate}))
+	case *hourly != "":
+		rate, err := employee.ParseCents(*hourly)
+		if err != nil {
+			return fmt.Errorf("-hourly: %w", err)
+		}
+		opts = append(opts, employee.WithPay(employee.Pay{Basis: employee.Hourly, Rate: rate}))
+	}
+	e, err := employee.New(*first, *last, 0, 0, opts...)
+	if err != nil {
+		return err
+	}
+	e, err = c.repo.Create(e)
+	if err != nil {
+		return err
+	}
+	return c.writeEmployee(e.Record(), e.Balance())
+}
+
+func list(c *cli, args []string) error {
+	if err := parse(flags("list"), args, 0, false); err != nil {
+		return err
+	}
+	records, err := c.records()
+	if err != nil {
+		return err
+	}
+	return c.writeList(records)
+}
+
+func show(c *cli, args []string) error {
+	fs := flags("show")
+	if err := parse(fs, args, 1, false); err != nil {
+		return err
+	}
+	id, err := c.find(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	e, err := c.repo.Get(id)
+	if err != nil {
+		return err
+	}
+	return c.writeEmployee(e.Record(), e.Balance())
+}
+
+func takeLeave(c *cli, args []string) error {
+	fs := flags("take-leave")
+	typ := fs.String("type", string(employee.Annual), "the type of leave")
+	if err := parse(fs, args, 2, false); err != nil {
+		return err
+	}
+	id, err := c.find(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	amount, err := employee.ParseAmount(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	e, err := c.leaves.Take(id, employee.LeaveType(*typ), amount)
+	if err != nil {
+		return err
+	}
+	return employee.Render(c.out, c.format, e.Balance())
+}
+
+func balance(c *cli, args []string) error {
+	fs := flags("balance")
+	if err := parse(fs, args, 0, true); err != nil {
+		return err
+	}
+	ids := fs.Args()
+	if len(ids) == 0 {
+		records, err := c.records()
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+	}
+	var balances []employee.Balance
+	for _, arg := range ids {
+		id, err := c.find(arg)
+		if err != nil {
+			return err
+		}
+		b, err := c.leaves.Balance(id)
+		if err != nil {
+			return err
+		}
+		balances = append(balances, b)
+	}
+	return employee.Render(c.out, c.format, balances...)
+}
+
+// importEmployees adds every employee in a JSON file written by export, or in a CSV file with a header row.
+// All of them are checked before any is added, so a file with a mistake in it adds nobody.
+func importEmployees(c *cli, args []string) error {
+	fs := flags("import")
+	asCSV := fs.Bool("csv", false, "read CSV, which is the default for files ending in .csv")
+	dryRun := fs.Bool("dry-run", false, "check the file and show who would be added, without adding them")
+	headers := fs.String("map", "", "CSV headers which are not named like the columns, such as Surname=lastName,Days=totalLeaves")
+	ignore := fs.String("ignore", "", "CSV headers whose columns are skipped, such as Notes,Manager")
+	if err := parse(fs, args, 1, false); err != nil {
+		return err
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var added []employee.Record
+	if *asCSV || strings.EqualFold(filepath.Ext(fs.Arg(0)), ".csv") {
+		opts := employee.CSVOptions{DryRun: *dryRun, Headers: make(map[string]string)}
+		for _, m := range split(*headers) {
+			h, col, ok := strings.Cut(m, "=")
+			if !ok {
+				return fmt.Errorf("-map: %q is not header=column", m)
+			}
+			opts.Headers[strings.TrimSpace(h)] = strings.TrimSpace(col)
+		}
+		opts.Ignore = split(*ignore)
+		added, err = employee.ImportCSV(c.repo, f, opts)
+	} else {
+		added, err = c.importJSON(f, *dryRun)
+	}
+	if err != nil {
+		var invalid *employee.CSVError
+		if errors.As(err, &invalid) {
+			// one row a line reads better than one long line
+			for _, r := range invalid.Rows {
+				fmt.Fprintf(c.errOut, "%s:%d: %v\n", fs.Arg(0), r.Line, r.Err)
+			}
+			return fmt.Errorf("%s: %d invalid rows, nobody was added", fs.Arg(0), len(invalid.Rows))
+		}
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	return c.writeList(added)
+}
+
+// importJSON adds the employees in a JSON array of records
+func (c *cli) importJSON(r io.Reader, dryRun bool) ([]employee.Record, error) {
+	var records []employee.Record
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+	// like ImportCSV, the ids are checked against each other as well as the repository, so Create can't fail half way through
+	seen := make(map[string]int)
+	for i, r := range records {
+		e, err := r.Employee()
+		if err != nil {
+			return nil, fmt.Errorf("employee %d: %w", i+1, err)
+		}
+		// keep the id Employee gave employees without one, so they are added with it below
+		records[i] = e.Record()
+		if first, ok := seen[e.ID()]; ok {
+			return nil, fmt.Errorf("employee %d: id %s is also employee %d's: %w", i+1, e.ID(), first, employee.ErrExists)
+		}
+		seen[e.ID()] = i + 1
+		if _, err := c.repo.Get(e.ID()); err == nil {
+			return nil, fmt.Errorf("employee %d: id %s: %w", i+1, e.ID(), employee.ErrExists)
+		} else if !errors.Is(err, employee.ErrNotFound) {
+			return nil, err
+		}
+	}
+	if dryRun {
+		return records, nil
+	}
+	var added []employee.Record
+	for i, r := range records {
+		e, _ := r.Employee()
+		e, err := c.repo.Create(e)
+		if err != nil {
+			return nil, fmt.Errorf("employee %d: %w, %d added before it", i+1, err, i)
+		}
+		added = append(added, e.Record())
+	}
+	return added, nil
+}
+
+// exportEmployees writes every employee as JSON, which import and the data file both read, or as CSV
+func exportEmployees(c *cli, args []string) error {
+	fs := flags("export")
+	asCSV := fs.Bool("csv", false, "write CSV, which is the default for files ending in .csv")
+	if err := parse(fs, args, 0, true); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errUsage
+	}
+	records, err := c.records()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if *asCSV || strings.EqualFold(filepath.Ext(fs.Arg(0)), ".csv") {
+		err = employee.WriteCSV(&buf, records)
+	} else {
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(records)
+	}
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		_, err = c.out.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(fs.Arg(0), buf.Bytes(), 0o644)
+}
+
+// split splits a comma separated flag, it returns nil for an empty one
+func split(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+	}
+	return parts
+}
+
+// records returns every employee as a Record, sorted by name
+func (c *cli) records() ([]employee.Record, error) {
+	es, err := c.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]employee.Record, len(es))
+	for i, e := range es {
+		records[i] = e.Record()
+	}
+	return records, nil
+}
+
+// find returns the id of the employee named by arg, which is their id or the start of it
+func (c *cli) find(arg string) (string, error) {
+	if _, err := c.repo.Get(arg); !errors.Is(err, employee.ErrNotFound) {
+		return arg, err
+	}
+	records, err := c.records()
+	if err != nil {
+		return "", err
+	}
+	var ids []string
+	for _, r := range records {
+		if strings.HasPrefix(r.ID, arg) {
+			ids = append(ids, r.ID)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("%s: %w", arg, employee.ErrNotFound)
+	case 1:
+		return ids[0], nil
+	}
+	return "", fmt.Errorf("%s is the start of %d ids, give more of it", arg, len(ids))
+}
+
+func (c *cli) writeJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c *cli) writeList(records []employee.Record) error {
+	if c.format == employee.JSON {
+		if records == nil {
+			// an empty array rather than null
+			records = []employee.Record{}
+		}
+		return c.writeJSON(records)
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTARTED\tREGION\tANNUAL\tREMAINING")
+	for _, r := range records {
+		fmt.Fprintf(tw, "%s\t%s %s\t%s\t%s\t%s\t%s\n", r.ID, r.FirstName, r.LastName, dash(r.StartDate), dash(r.Region), r.TotalLeaves, r.TotalLeaves-r.LeavesTaken)
+	}
+	return tw.Flush()
+}
+
+// employeeJSON is how show and add write an employee as JSON
+type employeeJSON struct {
+	Employee employee.Record  `json:"employee"`
+	Balance  employee.Balance `json:"balance"`
+}
+
+// writeEmployee writes an employee's details followed by their leave
+func (c *cli) writeEmployee(r employee.Record, b employee.Balance) error {
+	if c.format == employee.JSON {
+		return c.writeJSON(employeeJSON{Employee: r, Balance: b})
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID\t%s\n", r.ID)
+	fmt.Fprintf(tw, "Name\t%s %s\n", r.FirstName, r.LastName)
+	fmt.Fprintf(tw, "Started\t%s\n", dash(r.StartDate))
+	fmt.Fprintf(tw, "Region\t%s\n", dash(r.Region))
+	switch {
+	case r.Pay == nil:
+		fmt.Fprintln(tw, "Pay\t-")
+	case r.Pay.Basis == employee.Hourly:
+		fmt.Fprintf(tw, "Pay\t%s an hour\n", r.Pay.Rate)
+	default:
+		fmt.Fprintf(tw, "Pay\t%s a year\n", r.Pay.Rate)
+	}
+	fmt.Fprintf(tw, "Version\t%d\n", r.Version)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out)
+	return employee.Render(c.out, employee.Table, b)
+}
+
+// dash stands in for fields which are not filled in
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"oop/employee"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// staff is the data file every test starts from
+const staff = `[
+  {"id": "ada-1", "version": 1, "firstName": "Ada", "lastName": "Lovelace", "totalLeaves": 25, "leavesTaken": 5,
+   "startDate": "2024-01-15", "pay": {"basis": "hourly", "rateCents": 3150}},
+  {"id": "ada-2", "version": 1, "firstName": "Ada", "lastName": "Byron", "totalLeaves": 20, "leavesTaken": 0},
+  {"id": "grace", "version": 1, "firstName": "Grace", "lastName": "Hopper", "totalLeaves": 30, "leavesTaken": 10, "region": "US"}
+]`
+
+// dataFile writes staff to a data file of its own for one test
+func dataFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "employees.json")
+	if err := os.WriteFile(path, []byte(staff), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// run runs the command in args on the data file like main does, and returns what it wrote
+func run(t *testing.T, data string, format employee.Format, args ...string) (string, error) {
+	t.Helper()
+	repo := employee.NewFileRepository(data)
+	var out, errOut bytes.Buffer
+	c := &cli{repo: repo, leaves: employee.NewLeaves(repo), format: format, out: &out, errOut: &errOut}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			err := cmd.run(c, args[1:])
+			// what goes to standard error is only looked at when there is an error
+			if errOut.Len() > 0 && err != nil {
+				err = fmt.Errorf("%s%w", errOut.String(), err)
+			}
+			return out.String(), err
+		}
+	}
+	t.Fatalf("no command %s", args[0])
+	return "", nil
+}
+
+func TestCommands(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		args []string
+		// before are commands run first, whose output doesn't matter
+		before [][]string
+		want   string
+		// err is checked with errors.Is if it is errUsage or employee.ErrNotFound, and by its message otherwise
+		err error
+	}{
+		{
+			name: "list",
+			args: []string{"list"},
+			want: `ID     NAME          STARTED     REGION  ANNUAL  REMAINING
+ada-2  Ada Byron     -           -       20      20
+grace  Grace Hopper  -           US      30      20
+ada-1  Ada Lovelace  2024-01-15  -       25      20
+`,
+		},
+		{
+			name: "show by the start of the id",
+			args: []string{"show", "gr"},
+			want: `ID       grace
+Name     Grace Hopper
+Started  -
+Region   US
+Pay      -
+Version  1
+
+NAME          TOTAL  TAKEN  PENDING  REMAINING
+Grace Hopper  30     10     0        20
+  annual      30     10     0        20
+`,
+		},
+		{
+			name: "take leave",
+			args: []string{"take-leave", "ada-1", "2.5"},
+			want: `NAME          TOTAL  TAKEN  PENDING  REMAINING
+Ada Lovelace  25     7.5    0        17.5
+  annual      25     7.5    0        17.5
+`,
+		},
+		{
+			name:   "balance after taking unpaid leave",
+			args:   []string{"balance", "grace"},
+			before: [][]string{{"take-leave", "-type", "unpaid", "grace", "3h"}},
+			want: `NAME          TOTAL  TAKEN  PENDING  REMAINING
+Grace Hopper  30     10     0        20
+  annual      30     10     0        20
+  unpaid      -      0.375  0        -
+`,
+		},
+		{name: "more leave than is left", args: []string{"take-leave", "ada-2", "21"}, err: errors.New("employee ada-2 asked for 21 days of annual leave but has 20 remaining")},
+		{name: "ambiguous id", args: []string{"show", "ada"}, err: errors.New("ada is the start of 2 ids, give more of it")},
+		{name: "unknown id", args: []string{"balance", "ada-1", "alan"}, err: employee.ErrNotFound},
+		{name: "missing id", args: []string{"show"}, err: errUsage},
+		{name: "too many arguments", args: []string{"take-leave", "grace", "1", "2"}, err: errUsage},
+		{name: "unknown flag", args: []string{"list", "-all"}, err: errUsage},
+		{name: "paid twice", args: []string{"add", "-first", "Alan", "-last", "Turing", "-salary", "1", "-hourly", "1"}, err: errors.New("an employee is paid either a -salary or -hourly, not both")},
+		{name: "bad amount", args: []string{"add", "-first", "Alan", "-last", "Turing", "-leave", "x"}, err: errors.New(`-leave: invalid amount of leave "x"`)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			data := dataFile(t)
+			for _, args := range tt.before {
+				if _, err := run(t, data, employee.Table, args...); err != nil {
+					t.Fatalf("%v: %v", args, err)
+				}
+			}
+			out, err := run(t, data, employee.Table, tt.args...)
+			switch {
+			case tt.err == nil && err != nil:
+				t.Fatalf("error %v", err)
+			case tt.err == errUsage || tt.err == employee.ErrNotFound:
+				if !errors.Is(err, tt.err) {
+					t.Errorf("error %v, want %v", err, tt.err)
+				}
+			case tt.err != nil:
+				if err == nil || err.Error() != tt.err.Error() {
+					t.Errorf("error %v, want %q", err, tt.err)
+				}
+			}
+			if out != tt.want {
+				t.Errorf("wrote\n%s\nwant\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddAndShowJSON(t *testing.T) {
+	data := dataFile(t)
+	out, err := run(t, data, employee.JSON, "add", "-first", "Alan", "-last", "Turing", "-leave", "28", "-taken", "3", "-salary", "52000", "-start", "2024-03-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var added employeeJSON
+	if err := json.Unmarshal([]byte(out), &added); err != nil {
+		t.Fatalf("%v in\n%s", err, out)
+	}
+
+	// show writes the employee and their balance, like the table does
+	out, err = run(t, data, employee.JSON, "show", added.Employee.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shown employeeJSON
+	if err := json.Unmarshal([]byte(out), &shown); err != nil {
+		t.Fatalf("%v in\n%s", err, out)
+	}
+	r := shown.Employee
+	if r.FirstName != "Alan" || r.StartDate != "2024-03-01" || r.Pay == nil || r.Pay.Rate != 5200000 {
+		t.Errorf("showed %+v", r)
+	}
+	if b := shown.Balance; b.EmployeeID != r.ID || b.Total != employee.Days(28) || b.Remaining != employee.Days(25) {
+		t.Errorf("showed the balance %+v, want 25 of 28 days left", b)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	for _, file := range []string{"staff.json", "staff.csv"} {
+		t.Run(file, func(t *testing.T) {
+			from := dataFile(t)
+			path := filepath.Join(t.TempDir(), file)
+			if _, err := run(t, from, employee.Table, "export", path); err != nil {
+				t.Fatal(err)
+			}
+			want, err := run(t, from, employee.Table, "list")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			to := filepath.Join(t.TempDir(), "employees.json")
+			// a dry run only shows who would be added
+			if out, err := run(t, to, employee.Table, "import", "-dry-run", path); err != nil || out != want {
+				t.Errorf("dry run: error %v, wrote\n%s\nwant\n%s", err, out, want)
+			}
+			if out, err := run(t, to, employee.Table, "list"); err != nil || strings.Count(out, "\n") != 1 {
+				t.Errorf("after the dry run: error %v, listed\n%s\nwant nobody", err, out)
+			}
+
+			if _, err := run(t, to, employee.Table, "import", path); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := run(t, to, employee.Table, "list"); err != nil || got != want {
+				t.Errorf("imported: error %v, listed\n%s\nwant\n%s", err, got, want)
+			}
+			// importing again would add everybody twice, so nobody is added
+			_, err = run(t, to, employee.Table, "import", path)
+			if err == nil || !strings.Contains(err.Error(), "id ada-2: employee already exists") {
+				t.Errorf("importing twice: got %v, want ada-2 to already exist", err)
+			}
+			if got, err := run(t, to, employee.Table, "list"); err != nil || got != want {
+				t.Errorf("imported twice: error %v, listed\n%s\nwant\n%s", err, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Command employees keeps employees and their leave in a local JSON file, the same file cmd/server serves.
+//
+//	go run ./cmd/employees add -first Sam -last Adolf -leave 30 -taken 20
+//	go run ./cmd/employees list
+//	go run ./cmd/employees take-leave 3c0b5e1d 2.5
+//	go run ./cmd/employees -o json balance
+//	go run ./cmd/employees import -dry-run -map Surname=lastName staff.csv
+//
+// Employees can be named by the start of their id, as long as only one id starts that way.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"oop/employee"
+	"os"
+)
+
+// A command is one subcommand of employees
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+var commands = []command{
+	{"add", "-first name -last name [-leave days] [-taken days] [-start date] [-region region] [-salary money | -hourly money]", "add an employee", add},
+	{"list", "", "list the employees", list},
+	{"show", "id", "show an employee and their leave", show},
+	{"take-leave", "[-type type] id amount", "take leave, such as 2, 0.5 or 3h", takeLeave},
+	{"balance", "[id...]", "show the leave balance of some or all employees", balance},
+	{"import", "[-csv] [-dry-run] [-map header=column,...] [-ignore header,...] file", "add the employees in a JSON or CSV file", importEmployees},
+	{"export", "[-csv] [file]", "write the employees as JSON or CSV, to standard output if there is no file", exportEmployees},
+}
+
+// cli is what every command works on
+type cli struct {
+	repo   employee.Repository
+	leaves *employee.Leaves
+	// format is Table or JSON
+	format employee.Format
+	out    io.Writer
+	// errOut is for the problems a command finds on the way, such as every invalid row of an import
+	errOut io.Writer
+}
+
+// errUsage is returned by commands whose arguments are wrong, after they have printed why
+var errUsage = errors.New("usage")
+
+func main() {
+	data := flag.String("data", "employees.json", "the JSON file the employees are kept in")
+	output := flag.String("o", "table", "the output format, table or json")
+	flag.Usage = usage
+	flag.Parse()
+
+	format, err := employee.ParseFormat(*output)
+	if err != nil || format == employee.Text {
+		fmt.Fprintf(os.Stderr, "employees: unknown output format %q, want table or json\n", *output)
+		os.Exit(2)
+	}
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	repo := employee.NewFileRepository(*data)
+	c := &cli{repo: repo, leaves: employee.NewLeaves(repo), format: format, out: os.Stdout, errOut: os.Stderr}
+	for _, cmd := range commands {
+		if cmd.name != flag.Arg(0) {
+			continue
+		}
+		err := cmd.run(c, flag.Args()[1:])
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: employees %s %s\n", cmd.name, cmd.args)
+			os.Exit(2)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "employees:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "employees: unknown command %q\n", flag.Arg(0))
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintln(w, "usage: employees [-data file] [-o table|json] command [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s  %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "flags:")
+	flag.PrintDefaults()
+}
@@ -0,0 +1,26 @@
+// Command server serves the employees in a JSON file over HTTP, see the api package for the routes.
+//
+//	go run ./cmd/server -data employees.json
+//	curl -X POST localhost:8080/employees -d '{"firstName":"Sam","lastName":"Adolf","totalLeaves":30,"leavesTaken":20}'
+//	curl localhost:8080/openapi.json
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"oop/employee"
+	"oop/employee/api"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "the address to listen on")
+	data := flag.String("data", "employees.json", "the JSON file the employees are kept in")
+	flag.Parse()
+
+	repo := employee.NewFileRepository(*data)
+	// leave requests are only kept in memory, the leave employees have taken is saved with them in the data file
+	leaves := employee.NewLeaves(repo)
+	log.Printf("serving %s on http://%s", *data, *addr)
+	log.Fatal(http.ListenAndServe(*addr, api.New(repo, leaves)))
+}
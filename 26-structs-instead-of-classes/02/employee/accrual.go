@@ -0,0 +1,201 @@
+package employee
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// A Policy decides how much leave of a type, usually annual leave, an employee has earned by a given day.
+// Different teams can have different HR rules by giving them different Policies, see Policies.
+type Policy interface {
+	// TotalLeaves returns all the leave e has earned from their start date up to and including at,
+	// less what was lost because it was not taken in time.
+	// It is the Total of the employee's Allowance on that day, so Total - Taken is still what they have left.
+	TotalLeaves(e Employee, taken Usage, at time.Time) (Amount, error)
+}
+
+// Usage returns the leave taken from from up to, but not including, to.
+// Carry-over and expiry rules need to know when leave was taken, not only how much.
+type Usage func(from, to time.Time) Amount
+
+// ErrNoStartDate is returned by policies which need the employee's start date when it is not known, see WithStartDate.
+var ErrNoStartDate = errors.New("employee has no start date")
+
+// An Accrual decides how much leave is earned in a year.
+type Accrual interface {
+	// Earned returns the leave earned in year up to and including the day at, by an employee who joined on start.
+	Earned(start time.Time, year int, at time.Time) Amount
+}
+
+// Monthly earns Leave on the first of every month.
+// Employees who join part way through a month start earning on the first of the next one.
+type Monthly struct {
+	Leave Amount
+}
+
+func (m Monthly) Earned(start time.Time, year int, at time.Time) Amount {
+	start, at = day(start), day(at)
+	var earned Amount
+	for month := time.January; month <= time.December; month++ {
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		if first.After(at) {
+			break
+		}
+		if !first.Before(start) {
+			earned += m.Leave
+		}
+	}
+	return earned
+}
+
+// YearlyGrant gives Leave on the first of January, and on the day an employee joins.
+// If ProRate is set, an employee who joins part way through the year only gets a share of Leave
+// for the months which are left, counting the month they join.
+// The share is rounded down to a multiple of RoundTo, or to a quarter hour if RoundTo is zero.
+type YearlyGrant struct {
+	Leave   Amount
+	ProRate bool
+	RoundTo Amount
+}
+
+func (y YearlyGrant) Earned(start time.Time, year int, at time.Time) Amount {
+	start, at = day(start), day(at)
+	grant := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if start.Year() == year {
+		grant = start
+	}
+	if grant.After(at) || start.Year() > year {
+		return 0
+	}
+	if y.ProRate && start.Year() == year {
+		months := Amount(time.December-start.Month()) + 1
+		return (y.Leave * months / 12).Round(y.RoundTo, RoundDown)
+	}
+	return y.Leave
+}
+
+// UnlimitedCarryOver lets every unused day carry over into the next year.
+const UnlimitedCarryOver Amount = -1
+
+// Rules is a Policy made of an Accrual and what happens to days which are not taken by the end of the year.
+type Rules struct {
+	Accrual Accrual
+	// MaxCarryOver is how much unused leave is kept when a year ends, the rest is lost.
+	// Zero means nothing is kept, UnlimitedCarryOver means everything is.
+	MaxCarryOver Amount
+	// CarryOverExpiry is how many months into the new year the days carried over can be taken.
+	// Whatever is left of them after that is lost. Zero means they don't expire.
+	CarryOverExpiry int
+}
+
+func (r Rules) TotalLeaves(e Employee, taken Usage, at time.Time) (Amount, error) {
+	if e.StartDate().IsZero() {
+		return 0, ErrNoStartDate
+	}
+	start, at := day(e.StartDate()), day(at)
+	var total Amount
+	for year := start.Year(); year <= at.Year(); year++ {
+		newYear := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		if year > start.Year() {
+			// what was not taken last year is carried over, up to MaxCarryOver
+			carried := total - taken(start, newYear)
+			if r.MaxCarryOver != UnlimitedCarryOver && carried > r.MaxCarryOver {
+				total -= carried - r.MaxCarryOver
+				carried = r.MaxCarryOver
+			}
+			// leave taken before the carried over days expire is taken from them first
+			if expires := newYear.AddDate(0, r.CarryOverExpiry, 0); r.CarryOverExpiry > 0 && !at.Before(expires) {
+				if unused := carried - taken(newYear, expires); unused > 0 {
+					total -= unused
+				}
+			}
+		}
+		total += r.Accrual.Earned(start, year, at)
+	}
+	return total, nil
+}
+
+// Policies picks the Policy of a team, so HR rules can differ between teams.
+type Policies struct {
+	// Default is used for teams which don't have a Policy of their own
+	Default Policy
+	Teams   map[string]Policy
+}
+
+// For returns the Policy of team.
+func (p Policies) For(team string) Policy {
+	if policy, ok := p.Teams[team]; ok {
+		return policy
+	}
+	return p.Default
+}
+
+// Taken returns the Usage of leave type t by an employee as it is stored now.
+// It adds up the employee's LeaveDays, so it counts leave however it was taken: approved requests, Take and Employee.TakeLeave.
+// Leave which was not taken one of those ways, such as the leavesTaken given to New, has no day.
+// It is counted as taken on the employee's start date, the earliest it can have been taken,
+// so it still reduces what is carried over at the end of their first year.
+// An employee who can't be found has taken nothing.
+func (l *Leaves) Taken(employeeID string, t LeaveType) Usage {
+	var days []LeaveDay
+	if e, err := l.repo.Get(employeeID); err == nil {
+		v := e.value()
+		undated := v.Allowance(t).Taken
+		for _, d := range v.leaveDays {
+			if d.Type == t {
+				days = append(days, d)
+				undated -= d.Amount
+			}
+		}
+		if undated > 0 {
+			days = append(days, LeaveDay{Type: t, Day: v.startDate, Amount: undated})
+		}
+	}
+	return func(from, to time.Time) Amount {
+		from, to = day(from), day(to)
+		var taken Amount
+		for _, d := range days {
+			if !d.Day.Before(from) && d.Day.Before(to) {
+				taken += d.Amount
+			}
+		}
+		return taken
+	}
+}
+
+// Accrue sets the Total of the employee's leave of type t to what policy says they have earned by at, and stores it.
+func (l *Leaves) Accrue(employeeID string, t LeaveType, policy Policy, at time.Time) (Employee, error) {
+	if err := checkType(t); err != nil {
+		return nil, err
+	}
+	taken := l.Taken(employeeID, t)
+	for {
+		e, err := l.repo.Get(employeeID)
+		if err != nil {
+			return nil, err
+		}
+		total, err := policy.TotalLeaves(e, taken, at)
+		if err != nil {
+			return nil, err
+		}
+		accrued, err := e.value().accrued(t, total)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := l.repo.Update(accrued)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) {
+			return updated, err
+		}
+	}
+}
+
+// accrued returns the employee with the Total of their leave of type t set to total
+func (e employee) accrued(t LeaveType, total Amount) (Employee, error) {
+	if e.Allowance(t).Total == total {
+		return e, nil
+	}
+	e.allowance(t).Total = total
+	return e.changed(LeaveAccrued, fmt.Sprintf("%s days of %s leave in total", total, t))
+}
@@ -0,0 +1,110 @@
+package employee
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// usage returns the Usage of leave taken on the given days
+func usage(days map[string]Amount) Usage {
+	return func(from, to time.Time) Amount {
+		var taken Amount
+		for d, a := range days {
+			if t := date(d); !t.Before(from) && t.Before(to) {
+				taken += a
+			}
+		}
+		return taken
+	}
+}
+
+func TestCarryOver(t *testing.T) {
+	// 20 days on the first of January, from 2023 on
+	e, err := New("Ada", "Lovelace", 0, 0, WithStartDate(date("2023-01-01")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name   string
+		max    Amount
+		expiry int
+		taken  map[string]Amount
+		at     string
+		// total is what was earned less what was lost, 20 days a year with nothing lost makes 40 in 2024
+		total Amount
+	}{
+		{"under the cap", Days(5), 0, map[string]Amount{"2023-06-01": Days(17)}, "2024-02-01", Days(40)},
+		{"at the cap", Days(5), 0, map[string]Amount{"2023-06-01": Days(15)}, "2024-02-01", Days(40)},
+		{"over the cap", Days(5), 0, map[string]Amount{"2023-06-01": Days(10)}, "2024-02-01", Days(35)},
+		{"nothing carried over", 0, 0, map[string]Amount{"2023-06-01": Days(10)}, "2024-02-01", Days(30)},
+		{"unlimited", UnlimitedCarryOver, 0, map[string]Amount{"2023-06-01": Days(10)}, "2024-02-01", Days(40)},
+		{"in the first year nothing is carried over", Days(5), 0, nil, "2023-12-31", Days(20)},
+
+		// the 5 days carried over can be taken until the end of March
+		{"before expiry", Days(5), 3, map[string]Amount{"2023-06-01": Days(10)}, "2024-03-31", Days(35)},
+		{"after expiry", Days(5), 3, map[string]Amount{"2023-06-01": Days(10)}, "2024-04-01", Days(30)},
+		{"after expiry with some taken", Days(5), 3, map[string]Amount{"2023-06-01": Days(10), "2024-02-01": Days(3)}, "2024-04-01", Days(33)},
+		{"after expiry with all taken", Days(5), 3, map[string]Amount{"2023-06-01": Days(10), "2024-02-01": Days(7)}, "2024-04-01", Days(35)},
+		// leave taken after the expiry doesn't save the carried over days
+		{"taken after expiry", Days(5), 3, map[string]Amount{"2023-06-01": Days(10), "2024-04-01": Days(5)}, "2024-04-01", Days(30)},
+		// each year only carries over from the one before it
+		{"two years later", Days(5), 3, map[string]Amount{"2023-06-01": Days(10), "2024-06-01": Days(20)}, "2025-02-01", Days(50)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Rules{Accrual: YearlyGrant{Leave: Days(20)}, MaxCarryOver: tt.max, CarryOverExpiry: tt.expiry}
+			total, err := r.TotalLeaves(e, usage(tt.taken), date(tt.at))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != tt.total {
+				t.Errorf("total leave on %s is %s days, want %s", tt.at, total, tt.total)
+			}
+		})
+	}
+
+	if _, err := (Rules{Accrual: Monthly{Leave: Day}}).TotalLeaves(Employee(employee{id: "x"}), usage(nil), date("2024-01-01")); err != ErrNoStartDate {
+		t.Errorf("without a start date: got %v, want ErrNoStartDate", err)
+	}
+}
+
+func TestUndatedLeaveReducesCarryOver(t *testing.T) {
+	repo := NewMemoryRepository()
+	leaves := NewLeaves(repo)
+	// 10 of the 20 days were taken before the employee was entered, so there is no day for them
+	e, err := New("Ada", "Lovelace", 20, 10, WithID("ada"), WithStartDate(date("2023-01-01")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Create(e); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leaves.Take("ada", Annual, Days(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	taken := leaves.Taken("ada", Annual)
+	if got := taken(date("2023-01-01"), date("2023-01-02")); got != Days(10) {
+		t.Errorf("taken on the start date: %s days, want the 10 without a day", got)
+	}
+	if got := taken(date("2023-01-01"), time.Now().AddDate(0, 0, 1)); got != Days(12) {
+		t.Errorf("taken in all: %s days, want 12", got)
+	}
+
+	// 10 of the 20 days of 2023 are left, and only 5 of them are carried over
+	policy := Rules{Accrual: YearlyGrant{Leave: Days(20)}, MaxCarryOver: Days(5)}
+	e, err = leaves.Accrue("ada", Annual, policy, date("2024-02-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total := e.Allowance(Annual).Total; total != Days(35) {
+		t.Errorf("total leave is %s days, want 35", total)
+	}
+}
@@ -0,0 +1,227 @@
+package employee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// An Amount of leave, counted in quarter hours so that half days and hours of leave can be taken.
+// It is a fixed-point number: all arithmetic is on whole quarter hours, so unlike float64 nothing is lost to rounding,
+// 0.1 + 0.2 days of leave can't turn into 0.30000000000000004.
+//
+// A working day is 8 hours, so a day is 32 quarter hours and any Amount can be written exactly as days with at most 5 decimals.
+type Amount int64
+
+const (
+	QuarterHour Amount = 1
+	Hour               = 4 * QuarterHour
+	QuarterDay         = 2 * Hour
+	HalfDay            = 4 * Hour
+	Day                = 8 * Hour
+)
+
+// Days returns n whole days of leave.
+func Days(n int) Amount {
+	return Amount(n) * Day
+}
+
+// Hours returns n whole hours of leave.
+func Hours(n int) Amount {
+	return Amount(n) * Hour
+}
+
+// Days returns the amount in days. It is meant for display, do arithmetic on the Amount itself.
+func (a Amount) Days() float64 {
+	return float64(a) / float64(Day)
+}
+
+// Hours returns the amount in hours. It is meant for display, do arithmetic on the Amount itself.
+func (a Amount) Hours() float64 {
+	return float64(a) / float64(Hour)
+}
+
+// String formats the amount as a number of days, such as 10, 2.5 or 0.125 for an hour.
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign = "-"
+		a = -a
+	}
+	whole, frac := a/Day, a%Day
+	if frac == 0 {
+		return sign + strconv.FormatInt(int64(whole), 10)
+	}
+	// 1/32 is 0.03125, so 5 decimals are always enough
+	decimals := fmt.Sprintf("%05d", int64(frac)*100000/int64(Day))
+	return sign + strconv.FormatInt(int64(whole), 10) + "." + strings.TrimRight(decimals, "0")
+}
+
+// Rounding is the direction Round goes in.
+type Rounding int
+
+const (
+	RoundDown Rounding = iota
+	RoundUp
+	// RoundNearest rounds halves up
+	RoundNearest
+)
+
+// Round rounds a to a multiple of unit, for example to whole days or half days. Negative amounts are rounded the same way as positive ones,
+// so RoundDown always makes an amount smaller in size.
+func (a Amount) Round(unit Amount, mode Rounding) Amount {
+	if unit <= 0 {
+		return a
+	}
+	if a < 0 {
+		return -(-a).Round(unit, mode)
+	}
+	down := a / unit * unit
+	rest := a - down
+	switch {
+	case rest == 0:
+		return a
+	case mode == RoundUp, mode == RoundNearest && 2*rest >= unit:
+		return down + unit
+	}
+	return down
+}
+
+// ErrAmount is returned when parsing an amount of leave which is not a whole number of quarter hours.
+var ErrAmount = errors.New("leave must be a whole number of quarter hours")
+
+// ParseAmount parses a number of days such as "1.5" or "1.5d", or of hours and minutes such as "3h" or "2h30m".
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("invalid amount of leave %q", s)
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		return parseDays(days)
+	}
+	if !strings.ContainsAny(s, "hm") {
+		return parseDays(s)
+	}
+
+	rest := s
+	var total Amount
+	for _, unit := range []struct {
+		suffix     string
+		perQuarter int64
+	}{{"h", 4}, {"m", 1}} {
+		n, after, ok := strings.Cut(rest, unit.suffix)
+		if !ok {
+			continue
+		}
+		if !isDigits(n) {
+			return 0, fmt.Errorf("invalid amount of leave %q", s)
+		}
+		v, err := strconv.ParseInt(n, 10, 64)
+		if err == nil && unit.suffix == "m" {
+			if v%15 != 0 {
+				return 0, fmt.Errorf("%q: %w", s, ErrAmount)
+			}
+			v /= 15
+		}
+		if err != nil || v > (math.MaxInt64-int64(total))/unit.perQuarter {
+			return 0, fmt.Errorf("amount of leave %q is out of range", s)
+		}
+		total += Amount(v * unit.perQuarter)
+		rest = after
+	}
+	if rest != "" {
+		return 0, fmt.Errorf("invalid amount of leave %q", s)
+	}
+	return total, nil
+}
+
+// isDigits reports whether s is one or more decimal digits, with no sign
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// parseDays parses a decimal number of days without going through float64, so it is exact
+func parseDays(s string) (Amount, error) {
+	number := s
+	neg := false
+	if after, ok := strings.CutPrefix(number, "-"); ok {
+		neg, number = true, after
+	}
+	whole, frac, point := strings.Cut(number, ".")
+	// there must be digits before the point, after it or both, such as 2, 2.5 or .5
+	if (whole == "" && frac == "") || (whole != "" && !isDigits(whole)) || (point && !isDigits(frac)) {
+		return 0, fmt.Errorf("invalid amount of leave %q", s)
+	}
+	var w int64
+	if whole != "" {
+		var err error
+		w, err = strconv.ParseInt(whole, 10, 64)
+		// the whole days and the fraction of a day after them have to fit in an Amount
+		if err != nil || w > math.MaxInt64/int64(Day) {
+			return 0, fmt.Errorf("amount of leave %q is out of range", s)
+		}
+	}
+	a := Amount(w) * Day
+	if frac != "" {
+		// frac/10^len(frac) of a day is frac*32/10^len(frac) quarter hours, which has to come out whole.
+		// More than 10 decimals can't be a whole number of quarter hours, and f*32 would overflow past 17.
+		if len(frac) > 10 {
+			return 0, fmt.Errorf("%q: %w", s, ErrAmount)
+		}
+		f, _ := strconv.ParseInt(frac, 10, 64)
+		scale := int64(1)
+		for range len(frac) {
+			scale *= 10
+		}
+		if f*int64(Day)%scale != 0 {
+			return 0, fmt.Errorf("%q: %w", s, ErrAmount)
+		}
+		a += Amount(f * int64(Day) / scale)
+	}
+	if neg {
+		a = -a
+	}
+	return a, nil
+}
+
+// MarshalJSON writes the amount as a JSON number of days, exactly as String formats it.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number of days, as written by MarshalJSON,
+// or a string in any form ParseAmount accepts, such as "2.5d" or "3h".
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return a.UnmarshalText([]byte(s))
+	}
+	v, err := parseDays(string(b))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// MarshalText writes the amount as a number of days, exactly as String formats it.
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText reads an amount in any form ParseAmount accepts,
+// so an Amount can be set with flag.TextVar or read from a quoted JSON string.
+func (a *Amount) UnmarshalText(b []byte) error {
+	v, err := ParseAmount(string(b))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
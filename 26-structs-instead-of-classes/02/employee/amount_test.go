@@ -0,0 +1,104 @@
+package employee
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want Amount
+		// err is part of the error message, empty if parsing succeeds
+		err string
+	}{
+		{in: "2", want: Days(2)},
+		{in: "2.5", want: Days(2) + HalfDay},
+		{in: "2.5d", want: Days(2) + HalfDay},
+		{in: ".5", want: HalfDay},
+		{in: "-1.25", want: -(Day + QuarterDay)},
+		{in: "0.03125", want: QuarterHour},
+		{in: "3h", want: Hours(3)},
+		{in: "2h30m", want: Hours(2) + 2*QuarterHour},
+		{in: "45m", want: 3 * QuarterHour},
+		{in: "288230376151711743.96875", want: math.MaxInt64},
+		{in: "0.1", err: "whole number of quarter hours"},
+		{in: "10m", err: "whole number of quarter hours"},
+		{in: "0.12345678901", err: "whole number of quarter hours"},
+		{in: "", err: "invalid"},
+		{in: "-", err: "invalid"},
+		{in: ".", err: "invalid"},
+		{in: "-.", err: "invalid"},
+		{in: "1.", err: "invalid"},
+		{in: "d", err: "invalid"},
+		{in: "--1", err: "invalid"},
+		{in: "+1", err: "invalid"},
+		{in: "1.-5", err: "invalid"},
+		{in: "h", err: "invalid"},
+		{in: "-3h", err: "invalid"},
+		{in: "3h5", err: "invalid"},
+		{in: "288230376151711744", err: "out of range"},
+		{in: "99999999999999999999", err: "out of range"},
+		{in: "2305843009213693952h", err: "out of range"},
+		{in: "2305843009213693951h60m", err: "out of range"},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseAmount(tt.in)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("ParseAmount(%q) failed: %v", tt.in, err)
+			case tt.err == "" && got != tt.want:
+				t.Errorf("ParseAmount(%q) = %d quarter hours, want %d", tt.in, got, tt.want)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("ParseAmount(%q) = %s, %v, want an error saying %q", tt.in, got, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseAmountWholeQuarterHours(t *testing.T) {
+	if _, err := ParseAmount("0.1"); !errors.Is(err, ErrAmount) {
+		t.Errorf("got %v, want ErrAmount", err)
+	}
+}
+
+func TestAmountStringRoundTrip(t *testing.T) {
+	for _, a := range []Amount{0, QuarterHour, Hour, HalfDay, Days(20) + 3*QuarterHour, -Days(2) - Hour, math.MaxInt64 / Day * Day} {
+		got, err := ParseAmount(a.String())
+		if err != nil || got != a {
+			t.Errorf("%d quarter hours formatted as %q parse back as %d, %v", a, a.String(), got, err)
+		}
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	var v struct {
+		A Amount `json:"a"`
+	}
+	for in, want := range map[string]Amount{
+		`{"a": 2.5}`:    Days(2) + HalfDay,
+		`{"a": "2.5"}`:  Days(2) + HalfDay,
+		`{"a": "2.5d"}`: Days(2) + HalfDay,
+		`{"a": "3h"}`:   Hours(3),
+	} {
+		v.A = 0
+		if err := json.Unmarshal([]byte(in), &v); err != nil || v.A != want {
+			t.Errorf("%s read as %s, %v, want %s", in, v.A, err, want)
+		}
+	}
+	for _, in := range []string{`{"a": "-"}`, `{"a": "."}`, `{"a": "2.5x"}`, `{"a": true}`} {
+		if err := json.Unmarshal([]byte(in), &v); err == nil {
+			t.Errorf("%s was read as %s, want an error", in, v.A)
+		}
+	}
+
+	b, err := json.Marshal(struct {
+		A Amount `json:"a"`
+	}{Days(1) + Hour})
+	if err != nil || string(b) != `{"a":1.125}` {
+		t.Errorf("writing 1.125 days: %s, %v", b, err)
+	}
+}
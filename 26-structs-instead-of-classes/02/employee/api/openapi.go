@@ -0,0 +1,159 @@
+package api
+
+import (
+	"net/http"
+	"oop/employee"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// OpenAPI returns the OpenAPI 3 document describing the server, generated from its routes and the Go types they read and write.
+func (s *Server) OpenAPI() map[string]any {
+	g := &schemas{components: make(map[string]any)}
+	paths := make(map[string]map[string]any)
+	for _, rt := range s.routes {
+		op := map[string]any{
+			"summary":     rt.summary,
+			"operationId": operationID(rt),
+		}
+		var params []any
+		for _, name := range pathParams(rt.path) {
+			params = append(params, map[string]any{"name": name, "in": "path", "required": true, "schema": map[string]any{"type": "string"}})
+		}
+		for _, q := range rt.query {
+			params = append(params, map[string]any{"name": q.name, "in": "query", "description": q.description, "schema": map[string]any{"type": "integer", "minimum": 0}})
+		}
+		if rt.ifMatch {
+			params = append(params, map[string]any{"name": "If-Match", "in": "header", "required": true,
+				"description": "the ETag of the employee, or * to skip the check", "schema": map[string]any{"type": "string"}})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+		if rt.request != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(rt.request))}},
+			}
+		}
+		ok := map[string]any{"description": http.StatusText(rt.status)}
+		if rt.response != nil {
+			ok["content"] = map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(rt.response))}}
+		}
+		op["responses"] = map[string]any{
+			strconv.Itoa(rt.status): ok,
+			"default": map[string]any{
+				"description": "an error",
+				"content":     map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(Error{}))}},
+			},
+		}
+		if paths[rt.path] == nil {
+			paths[rt.path] = make(map[string]any)
+		}
+		paths[rt.path][strings.ToLower(rt.method)] = op
+	}
+	return map[string]any{
+		"openapi":    "3.0.3",
+		"info":       map[string]any{"title": "Employees", "version": "1.0.0"},
+		"paths":      paths,
+		"components": map[string]any{"schemas": g.components},
+	}
+}
+
+// operationID turns "GET /employees/{id}/leave" into "getEmployeesIdLeave"
+func operationID(rt route) string {
+	id := strings.ToLower(rt.method)
+	for _, part := range strings.FieldsFunc(rt.path, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// pathParams returns the names of the {wildcards} in a path
+func pathParams(path string) []string {
+	var names []string
+	for _, part := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(part, "{"); ok {
+			names = append(names, strings.TrimSuffix(name, "}"))
+		}
+	}
+	return names
+}
+
+// schemas turns Go types into JSON schemas, collecting the structs as reusable components
+type schemas struct {
+	components map[string]any
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	amountType = reflect.TypeOf(employee.Amount(0))
+)
+
+func (g *schemas) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case amountType:
+		return map[string]any{"type": "number", "description": "days of leave, in steps of a quarter hour (1/32 of a day)"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.object(t)
+	}
+	// interfaces can hold anything
+	return map[string]any{}
+}
+
+// object adds the schema of a struct to the components, the first time it is seen, and returns a reference to it
+func (g *schemas) object(t reflect.Type) map[string]any {
+	ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	if _, ok := g.components[t.Name()]; ok {
+		return ref
+	}
+	// a placeholder stops a struct which contains itself from recursing forever
+	g.components[t.Name()] = nil
+	props := make(map[string]any)
+	var required []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	s := map[string]any{"type": "object", "properties": props}
+	if required != nil {
+		s["required"] = required
+	}
+	g.components[t.Name()] = s
+	return ref
+}
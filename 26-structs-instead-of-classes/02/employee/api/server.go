@@ -0,0 +1,456 @@
+// Package api serves employees, their leave requests and their balances over HTTP as JSON.
+//
+// Every employee has an ETag, which is its version. Changes must send it back in an If-Match header,
+// and are refused with 412 Precondition Failed if someone else has changed the employee since,
+// which is the optimistic concurrency of employee.Repository carried over HTTP.
+//
+// The routes are described by a table, which both registers the handlers and generates the OpenAPI document served at /openapi.json,
+// so the document can't drift away from what the server does.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"oop/employee"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+	// maxBody is the largest request body accepted, in bytes
+	maxBody = 1 << 20
+)
+
+// A Server is an http.Handler serving the employees in a Repository and their leave.
+type Server struct {
+	// ErrorLog logs the errors behind 500 Internal Server Error responses, which only tell the client that something went wrong.
+	// The log package's standard logger is used if it is nil.
+	ErrorLog *log.Logger
+
+	repo   employee.Repository
+	leaves *employee.Leaves
+	mux    *http.ServeMux
+	routes []route
+}
+
+// A route is one operation of the API
+type route struct {
+	method  string
+	path    string
+	summary string
+	// query lists the query parameters
+	query []param
+	// ifMatch is set for operations which need the employee's ETag in an If-Match header
+	ifMatch bool
+	// request is a value of the type of the JSON body, nil if there is none
+	request any
+	// status is the status of a successful response, and response a value of the type of its JSON body, nil if there is none
+	status   int
+	response any
+	handle   func(w http.ResponseWriter, r *http.Request) error
+}
+
+type param struct {
+	name        string
+	description string
+}
+
+// An EmployeePage is one page of the list of employees.
+type EmployeePage struct {
+	Items  []employee.Record `json:"items"`
+	Total  int               `json:"total"`
+	Offset int               `json:"offset"`
+	Limit  int               `json:"limit"`
+}
+
+// A LeaveInput asks for leave.
+type LeaveInput struct {
+	Type employee.LeaveType `json:"type"`
+	// From and To are dates such as 2024-07-01, To is From if it is empty
+	From string `json:"from"`
+	To   string `json:"to,omitempty"`
+	// Part asks for part of the day From, such as 0.5 for half a day
+	Part employee.Amount `json:"part,omitempty"`
+	Note string          `json:"note,omitempty"`
+}
+
+// A Decision approves or rejects a leave request.
+type Decision struct {
+	Manager string `json:"manager"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// An Error is the body of every response which is not a success.
+type Error struct {
+	Error string `json:"error"`
+	// Fields lists the invalid fields of the request, if that is what is wrong with it
+	Fields []employee.FieldError `json:"fields,omitempty"`
+}
+
+// New returns a Server for the employees in repo and their leave in leaves.
+func New(repo employee.Repository, leaves *employee.Leaves) *Server {
+	s := &Server{repo: repo, leaves: leaves, mux: http.NewServeMux()}
+	s.routes = []route{
+		{method: "GET", path: "/employees", summary: "List employees",
+			query:  []param{{"limit", fmt.Sprintf("the most employees to return, at most %d", maxLimit)}, {"offset", "the number of employees to skip"}},
+			status: http.StatusOK, response: EmployeePage{}, handle: s.listEmployees},
+		{method: "POST", path: "/employees", summary: "Create an employee",
+			request: employee.Record{}, status: http.StatusCreated, response: employee.Record{}, handle: s.createEmployee},
+		{method: "GET", path: "/employees/{id}", summary: "Get an employee",
+			status: http.StatusOK, response: employee.Record{}, handle: s.getEmployee},
+		{method: "PUT", path: "/employees/{id}", summary: "Replace an employee", ifMatch: true,
+			request: employee.Record{}, status: http.StatusOK, response: employee.Record{}, handle: s.updateEmployee},
+		{method: "DELETE", path: "/employees/{id}", summary: "Delete an employee", ifMatch: true,
+			status: http.StatusNoContent, handle: s.deleteEmployee},
+		{method: "GET", path: "/employees/{id}/balance", summary: "Get an employee's leave balance",
+			status: http.StatusOK, response: employee.Balance{}, handle: s.balance},
+		{method: "GET", path: "/employees/{id}/leave", summary: "List an employee's leave requests",
+			status: http.StatusOK, response: []employee.LeaveRequest{}, handle: s.listLeave},
+		{method: "POST", path: "/employees/{id}/leave", summary: "Ask for leave",
+			request: LeaveInput{}, status: http.StatusCreated, response: employee.LeaveRequest{}, handle: s.submitLeave},
+		{method: "GET", path: "/leave/{requestId}", summary: "Get a leave request",
+			status: http.StatusOK, response: employee.LeaveRequest{}, handle: s.getLeave},
+		{method: "POST", path: "/leave/{requestId}/approve", summary: "Approve a leave request",
+			request: Decision{}, status: http.StatusOK, response: employee.LeaveRequest{}, handle: s.decide(true)},
+		{method: "POST", path: "/leave/{requestId}/reject", summary: "Reject a leave request",
+			request: Decision{}, status: http.StatusOK, response: employee.LeaveRequest{}, handle: s.decide(false)},
+		{method: "GET", path: "/openapi.json", summary: "Get this document",
+			status: http.StatusOK, response: map[string]any{}, handle: s.openAPI},
+	}
+	for _, rt := range s.routes {
+		handle := rt.handle
+		s.mux.HandleFunc(rt.method+" "+rt.path, func(w http.ResponseWriter, r *http.Request) {
+			if err := handle(w, r); err != nil {
+				s.writeError(w, r, err)
+			}
+		})
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// statusError is an error with the HTTP status it should be reported with
+type statusError struct {
+	status int
+	err    error
+	fields []employee.FieldError
+}
+
+func (s *statusError) Error() string { return s.err.Error() }
+func (s *statusError) Unwrap() error { return s.err }
+
+func errorf(status int, format string, args ...any) error {
+	return &statusError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// invalidInput reports the fields of a request body which are not valid
+func invalidInput(fields []employee.FieldError) error {
+	problems := make([]string, len(fields))
+	for i, f := range fields {
+		problems[i] = f.Error()
+	}
+	return &statusError{status: http.StatusUnprocessableEntity, err: errors.New("invalid input: " + strings.Join(problems, "; ")), fields: fields}
+}
+
+// writeError reports err with the status that matches it.
+// Errors it doesn't know are logged rather than sent, as they may tell the client about the server's files and the like.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	body := Error{Error: err.Error()}
+	status := http.StatusInternalServerError
+	var se *statusError
+	var invalid *employee.ValidationError
+	var conflict *employee.ConflictError
+	var insufficient *employee.InsufficientLeaveError
+	switch {
+	case errors.As(err, &se):
+		status = se.status
+		body.Fields = se.fields
+	case errors.As(err, &invalid):
+		status = http.StatusUnprocessableEntity
+		body.Fields = invalid.Fields
+	case errors.As(err, &conflict):
+		status = http.StatusPreconditionFailed
+	case errors.As(err, &insufficient), errors.Is(err, employee.ErrExists), errors.Is(err, employee.ErrDecided):
+		status = http.StatusConflict
+	case errors.Is(err, employee.ErrNotFound), errors.Is(err, employee.ErrRequestNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, employee.ErrSelfApproval), errors.Is(err, employee.ErrNotManager):
+		status = http.StatusForbidden
+	}
+	if status == http.StatusInternalServerError {
+		logf := log.Printf
+		if s.ErrorLog != nil {
+			logf = s.ErrorLog.Printf
+		}
+		logf("%s %s: %v", r.Method, r.URL.Path, err)
+		body = Error{Error: "internal server error"}
+	}
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// readJSON decodes the request body into v, refusing fields v doesn't have so typos don't go unnoticed
+func readJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return errorf(http.StatusRequestEntityTooLarge, "request body is larger than %d bytes", tooLarge.Limit)
+		}
+		return errorf(http.StatusBadRequest, "invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// etag returns the ETag of an employee, its version in quotes
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatch returns the version in the If-Match header, or current if it is *.
+// A weak ETag such as W/"3" is refused, as only a strong comparison may allow a write.
+func ifMatch(r *http.Request, current int) (int, error) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" {
+		return 0, errorf(http.StatusPreconditionRequired, "If-Match header with the employee's ETag is required")
+	}
+	if h == "*" {
+		return current, nil
+	}
+	if strings.HasPrefix(h, "W/") {
+		return 0, errorf(http.StatusPreconditionFailed, "If-Match header %s is a weak ETag, send the employee's ETag as it was given", h)
+	}
+	v, err := strconv.Atoi(strings.Trim(h, `"`))
+	if err != nil {
+		return 0, errorf(http.StatusBadRequest, "invalid If-Match header %q", h)
+	}
+	return v, nil
+}
+
+// queryInt returns the query parameter name as a number between 0 and max, or def if it is not set
+func queryInt(r *http.Request, name string, def, max int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > max {
+		return 0, errorf(http.StatusBadRequest, "%s must be a number from 0 to %d", name, max)
+	}
+	return n, nil
+}
+
+func (s *Server) listEmployees(w http.ResponseWriter, r *http.Request) error {
+	limit, err := queryInt(r, "limit", defaultLimit, maxLimit)
+	if err != nil {
+		return err
+	}
+	offset, err := queryInt(r, "offset", 0, int(^uint(0)>>1))
+	if err != nil {
+		return err
+	}
+	all, err := s.repo.List()
+	if err != nil {
+		return err
+	}
+	page := EmployeePage{Items: []employee.Record{}, Total: len(all), Offset: offset, Limit: limit}
+	// offset can be as large as an int, so it is brought into the list before anything is added to it
+	start := min(offset, len(all))
+	end := start + min(limit, len(all)-start)
+	for _, e := range all[start:end] {
+		page.Items = append(page.Items, e.Record())
+	}
+	if end < len(all) {
+		w.Header().Set("Link", fmt.Sprintf(`</employees?offset=%d&limit=%d>; rel="next"`, end, limit))
+	}
+	return writeJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) createEmployee(w http.ResponseWriter, r *http.Request) error {
+	var rec employee.Record
+	if err := readJSON(r, &rec); err != nil {
+		return err
+	}
+	e, err := rec.Employee()
+	if err != nil {
+		return err
+	}
+	if e, err = s.repo.Create(e); err != nil {
+		return err
+	}
+	w.Header().Set("Location", "/employees/"+e.ID())
+	w.Header().Set("ETag", etag(e.Version()))
+	return writeJSON(w, http.StatusCreated, e.Record())
+}
+
+func (s *Server) getEmployee(w http.ResponseWriter, r *http.Request) error {
+	e, err := s.repo.Get(r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("ETag", etag(e.Version()))
+	if r.Header.Get("If-None-Match") == etag(e.Version()) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	return writeJSON(w, http.StatusOK, e.Record())
+}
+
+func (s *Server) updateEmployee(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+	stored, err := s.repo.Get(id)
+	if err != nil {
+		return err
+	}
+	version, err := ifMatch(r, stored.Version())
+	if err != nil {
+		return err
+	}
+	var rec employee.Record
+	if err := readJSON(r, &rec); err != nil {
+		return err
+	}
+	// the id and version come from the URL and the If-Match header, not the body
+	rec.ID, rec.Version = id, version
+	e, err := rec.Employee()
+	if err != nil {
+		return err
+	}
+	if e, err = s.repo.Update(e); err != nil {
+		return err
+	}
+	w.Header().Set("ETag", etag(e.Version()))
+	return writeJSON(w, http.StatusOK, e.Record())
+}
+
+func (s *Server) deleteEmployee(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+	stored, err := s.repo.Get(id)
+	if err != nil {
+		return err
+	}
+	version, err := ifMatch(r, stored.Version())
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id, version); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) balance(w http.ResponseWriter, r *http.Request) error {
+	b, err := s.leaves.Balance(r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, b)
+}
+
+func (s *Server) listLeave(w http.ResponseWriter, r *http.Request) error {
+	id := r.PathValue("id")
+	if _, err := s.repo.Get(id); err != nil {
+		return err
+	}
+	rs := s.leaves.Requests(id)
+	if rs == nil {
+		rs = []employee.LeaveRequest{}
+	}
+	return writeJSON(w, http.StatusOK, rs)
+}
+
+func (s *Server) submitLeave(w http.ResponseWriter, r *http.Request) error {
+	var in LeaveInput
+	if err := readJSON(r, &in); err != nil {
+		return err
+	}
+	var fields []employee.FieldError
+	from, err := time.Parse(time.DateOnly, in.From)
+	if err != nil {
+		fields = append(fields, employee.FieldError{Field: "from", Value: in.From, Problem: "must be a date such as 2006-01-02"})
+	}
+	to := from
+	if in.To != "" {
+		if to, err = time.Parse(time.DateOnly, in.To); err != nil {
+			fields = append(fields, employee.FieldError{Field: "to", Value: in.To, Problem: "must be a date such as 2006-01-02"})
+		}
+	}
+	if in.Part != 0 && in.To != "" && in.To != in.From {
+		fields = append(fields, employee.FieldError{Field: "part", Value: in.Part, Problem: "can only be asked for on a single day"})
+	}
+	if fields != nil {
+		return invalidInput(fields)
+	}
+
+	var lr employee.LeaveRequest
+	if in.Part != 0 {
+		lr, err = s.leaves.SubmitPart(r.PathValue("id"), in.Type, from, in.Part, in.Note)
+	} else {
+		lr, err = s.leaves.Submit(r.PathValue("id"), in.Type, from, to, in.Note)
+	}
+	if err != nil {
+		var invalid *employee.ValidationError
+		var insufficient *employee.InsufficientLeaveError
+		if errors.Is(err, employee.ErrNotFound) || errors.As(err, &invalid) || errors.As(err, &insufficient) {
+			return err
+		}
+		// the other errors of Submit are about what was asked for
+		return errorf(http.StatusUnprocessableEntity, "%v", err)
+	}
+	w.Header().Set("Location", "/leave/"+lr.ID)
+	return writeJSON(w, http.StatusCreated, lr)
+}
+
+func (s *Server) getLeave(w http.ResponseWriter, r *http.Request) error {
+	lr, err := s.leaves.Request(r.PathValue("requestId"))
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusOK, lr)
+}
+
+// decide returns the handler which approves or rejects a leave request
+func (s *Server) decide(approve bool) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var d Decision
+		if err := readJSON(r, &d); err != nil {
+			return err
+		}
+		if strings.TrimSpace(d.Manager) == "" {
+			return invalidInput([]employee.FieldError{{Field: "manager", Value: d.Manager, Problem: "must not be empty"}})
+		}
+		var lr employee.LeaveRequest
+		var err error
+		if approve {
+			lr, err = s.leaves.Approve(r.PathValue("requestId"), d.Manager)
+		} else {
+			lr, err = s.leaves.Reject(r.PathValue("requestId"), d.Manager, d.Reason)
+		}
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, http.StatusOK, lr)
+	}
+}
+
+func (s *Server) openAPI(w http.ResponseWriter, r *http.Request) error {
+	return writeJSON(w, http.StatusOK, s.OpenAPI())
+}
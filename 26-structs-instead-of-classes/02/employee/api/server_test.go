@@ -0,0 +1,269 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"oop/employee"
+	"oop/employee/api"
+	"strings"
+	"testing"
+)
+
+// do sends a request to h and returns the response, body is encoded as JSON unless it is a string
+func do(t *testing.T, h http.Handler, method, path string, body any, header ...string) *http.Response {
+	t.Helper()
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, r)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Result()
+}
+
+// decode checks the status of res and decodes its body into v
+func decode(t *testing.T, res *http.Response, status int, v any) {
+	t.Helper()
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != status {
+		t.Fatalf("status %d, want %d: %s", res.StatusCode, status, body)
+	}
+	if v != nil {
+		if err := json.Unmarshal(body, v); err != nil {
+			t.Fatalf("decoding %s: %v", body, err)
+		}
+	}
+}
+
+func newServer(t *testing.T, ids ...string) *api.Server {
+	t.Helper()
+	repo := employee.NewMemoryRepository()
+	s := api.New(repo, employee.NewLeaves(repo))
+	for _, id := range ids {
+		rec := employee.Record{ID: id, FirstName: "First", LastName: id, TotalLeaves: employee.Days(20)}
+		decode(t, do(t, s, "POST", "/employees", rec), http.StatusCreated, nil)
+	}
+	return s
+}
+
+func TestCreateAndGet(t *testing.T) {
+	s := newServer(t)
+	res := do(t, s, "POST", "/employees", `{"id":"sam","firstName":"Sam","lastName":"Adolf","totalLeaves":30,"leavesTaken":2.5}`)
+	var created employee.Record
+	decode(t, res, http.StatusCreated, &created)
+	if loc := res.Header.Get("Location"); loc != "/employees/sam" {
+		t.Errorf("Location is %q, want /employees/sam", loc)
+	}
+	if tag := res.Header.Get("ETag"); tag != `"1"` {
+		t.Errorf("ETag is %s, want \"1\"", tag)
+	}
+
+	var got employee.Record
+	decode(t, do(t, s, "GET", "/employees/sam", nil), http.StatusOK, &got)
+	if got.FirstName != "Sam" || got.LeavesTaken != employee.Days(2)+employee.HalfDay || got.Version != 1 {
+		t.Errorf("got %+v, want Sam with 2.5 days taken at version 1", got)
+	}
+	decode(t, do(t, s, "GET", "/employees/sam", nil, "If-None-Match", `"1"`), http.StatusNotModified, nil)
+	decode(t, do(t, s, "GET", "/employees/nobody", nil), http.StatusNotFound, nil)
+
+	for _, body := range []string{`{"firstName":"Sam"`, `{"firstName":"Sam","lastName":"Adolf","age":40}`} {
+		decode(t, do(t, s, "POST", "/employees", body), http.StatusBadRequest, nil)
+	}
+	var e api.Error
+	decode(t, do(t, s, "POST", "/employees", `{"firstName":"","lastName":"Adolf"}`), http.StatusUnprocessableEntity, &e)
+	if len(e.Fields) == 0 || e.Fields[0].Field != "firstName" {
+		t.Errorf("fields of the invalid employee are %+v, want firstName", e.Fields)
+	}
+	decode(t, do(t, s, "POST", "/employees", `{"id":"sam","firstName":"Sam","lastName":"Adolf"}`), http.StatusConflict, nil)
+}
+
+func TestIfMatch(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		ifMatch []string
+		status  int
+	}{
+		{"strong", []string{"If-Match", `"1"`}, http.StatusOK},
+		{"any", []string{"If-Match", "*"}, http.StatusOK},
+		{"stale", []string{"If-Match", `"2"`}, http.StatusPreconditionFailed},
+		{"weak", []string{"If-Match", `W/"1"`}, http.StatusPreconditionFailed},
+		{"missing", nil, http.StatusPreconditionRequired},
+		{"invalid", []string{"If-Match", `"one"`}, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t, "sam")
+			rec := employee.Record{FirstName: "Samuel", LastName: "Adolf", TotalLeaves: employee.Days(20)}
+			res := do(t, s, "PUT", "/employees/sam", rec, tt.ifMatch...)
+			decode(t, res, tt.status, nil)
+			if tt.status == http.StatusOK && res.Header.Get("ETag") != `"2"` {
+				t.Errorf("ETag after the update is %s, want \"2\"", res.Header.Get("ETag"))
+			}
+			var got employee.Record
+			decode(t, do(t, s, "GET", "/employees/sam", nil), http.StatusOK, &got)
+			if updated := got.FirstName == "Samuel"; updated != (tt.status == http.StatusOK) {
+				t.Errorf("status %d, but the first name is %s", tt.status, got.FirstName)
+			}
+		})
+	}
+
+	s := newServer(t, "sam")
+	decode(t, do(t, s, "DELETE", "/employees/sam", nil, "If-Match", `W/"1"`), http.StatusPreconditionFailed, nil)
+	decode(t, do(t, s, "DELETE", "/employees/sam", nil, "If-Match", `"1"`), http.StatusNoContent, nil)
+	decode(t, do(t, s, "GET", "/employees/sam", nil), http.StatusNotFound, nil)
+}
+
+func TestListPages(t *testing.T) {
+	s := newServer(t, "a", "b", "c", "d", "e")
+	for _, tt := range []struct {
+		query string
+		ids   string
+		next  string
+	}{
+		{"", "a b c d e", ""},
+		{"?limit=2", "a b", "/employees?offset=2&limit=2"},
+		{"?offset=2&limit=2", "c d", "/employees?offset=4&limit=2"},
+		{"?offset=4&limit=2", "e", ""},
+		{"?offset=5", "", ""},
+		{"?offset=9223372036854775807&limit=500", "", ""},
+		{"?limit=0", "", "/employees?offset=0&limit=0"},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			res := do(t, s, "GET", "/employees"+tt.query, nil)
+			var page api.EmployeePage
+			decode(t, res, http.StatusOK, &page)
+			var ids []string
+			for _, r := range page.Items {
+				ids = append(ids, r.ID)
+			}
+			if strings.Join(ids, " ") != tt.ids || page.Total != 5 {
+				t.Errorf("page has %v of %d employees, want %s of 5", ids, page.Total, tt.ids)
+			}
+			want := ""
+			if tt.next != "" {
+				want = "<" + tt.next + `>; rel="next"`
+			}
+			if link := res.Header.Get("Link"); link != want {
+				t.Errorf("Link is %q, want %q", link, want)
+			}
+		})
+	}
+	for _, query := range []string{"?limit=501", "?limit=-1", "?offset=x", "?offset=9223372036854775808"} {
+		decode(t, do(t, s, "GET", "/employees"+query, nil), http.StatusBadRequest, nil)
+	}
+}
+
+func TestBodyTooLarge(t *testing.T) {
+	s := newServer(t)
+	body := `{"firstName":"Sam","lastName":"` + strings.Repeat("a", 1<<20) + `"}`
+	var e api.Error
+	decode(t, do(t, s, "POST", "/employees", body), http.StatusRequestEntityTooLarge, &e)
+	if !strings.Contains(e.Error, "larger than") {
+		t.Errorf("error is %q, want it to say the body is too large", e.Error)
+	}
+}
+
+// brokenRepository fails to list its employees
+type brokenRepository struct {
+	employee.Repository
+}
+
+func (brokenRepository) List() ([]employee.Employee, error) {
+	return nil, errors.New("open /var/lib/employees.json: permission denied")
+}
+
+func TestInternalErrorsAreNotSent(t *testing.T) {
+	repo := brokenRepository{employee.NewMemoryRepository()}
+	s := api.New(repo, employee.NewLeaves(repo))
+	var logged bytes.Buffer
+	s.ErrorLog = log.New(&logged, "", 0)
+
+	var e api.Error
+	decode(t, do(t, s, "GET", "/employees", nil), http.StatusInternalServerError, &e)
+	if e.Error != "internal server error" {
+		t.Errorf("error sent is %q, want a generic message", e.Error)
+	}
+	if !strings.Contains(logged.String(), "GET /employees: open /var/lib/employees.json: permission denied") {
+		t.Errorf("logged %q, want the error", logged.String())
+	}
+}
+
+func TestLeave(t *testing.T) {
+	s := newServer(t, "sam", "boss")
+
+	// Monday to Wednesday
+	var lr employee.LeaveRequest
+	res := do(t, s, "POST", "/employees/sam/leave", api.LeaveInput{Type: employee.Annual, From: "2024-07-01", To: "2024-07-03"})
+	decode(t, res, http.StatusCreated, &lr)
+	if lr.Status != employee.Pending || lr.WorkingDays != 3 || res.Header.Get("Location") != "/leave/"+lr.ID {
+		t.Fatalf("got %+v at %s, want a pending request for 3 days", lr, res.Header.Get("Location"))
+	}
+
+	var b employee.Balance
+	decode(t, do(t, s, "GET", "/employees/sam/balance", nil), http.StatusOK, &b)
+	if b.Pending != employee.Days(3) || b.Taken != 0 {
+		t.Errorf("balance before approval is %+v, want 3 days pending", b)
+	}
+
+	decode(t, do(t, s, "POST", "/leave/"+lr.ID+"/approve", api.Decision{Manager: "sam"}), http.StatusForbidden, nil)
+	decode(t, do(t, s, "POST", "/leave/"+lr.ID+"/approve", api.Decision{}), http.StatusUnprocessableEntity, nil)
+	decode(t, do(t, s, "POST", "/leave/"+lr.ID+"/approve", api.Decision{Manager: "boss"}), http.StatusOK, &lr)
+	if lr.Status != employee.Approved || lr.DecidedBy != "boss" {
+		t.Errorf("got %+v, want it approved by boss", lr)
+	}
+	decode(t, do(t, s, "POST", "/leave/"+lr.ID+"/reject", api.Decision{Manager: "boss"}), http.StatusConflict, nil)
+
+	decode(t, do(t, s, "GET", "/employees/sam/balance", nil), http.StatusOK, &b)
+	if b.Pending != 0 || b.Taken != employee.Days(3) || b.Remaining != employee.Days(17) {
+		t.Errorf("balance after approval is %+v, want 3 days taken and 17 remaining", b)
+	}
+
+	var rs []employee.LeaveRequest
+	decode(t, do(t, s, "GET", "/employees/sam/leave", nil), http.StatusOK, &rs)
+	if len(rs) != 1 || rs[0].ID != lr.ID {
+		t.Errorf("requests are %+v, want the approved one", rs)
+	}
+	decode(t, do(t, s, "GET", "/leave/"+lr.ID, nil), http.StatusOK, nil)
+	decode(t, do(t, s, "GET", "/leave/999", nil), http.StatusNotFound, nil)
+
+	for _, in := range []api.LeaveInput{
+		{Type: employee.Annual, From: "2024-07-04", To: "2024-08-30"},
+		{Type: employee.Annual, From: "July 4th"},
+		{Type: employee.Annual, From: "2024-07-06", To: "2024-07-05"},
+	} {
+		res := do(t, s, "POST", "/employees/sam/leave", in)
+		if res.StatusCode != http.StatusConflict && res.StatusCode != http.StatusUnprocessableEntity {
+			t.Errorf("asking for %+v: status %d, want it refused", in, res.StatusCode)
+		}
+	}
+	decode(t, do(t, s, "POST", "/employees/nobody/leave", api.LeaveInput{Type: employee.Annual, From: "2024-07-01"}), http.StatusNotFound, nil)
+}
+
+func TestOpenAPI(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	decode(t, do(t, newServer(t), "GET", "/openapi.json", nil), http.StatusOK, &doc)
+	for path, method := range map[string]string{"/employees": "post", "/employees/{id}": "put", "/leave/{requestId}/approve": "post"} {
+		if doc.Paths[path][method] == nil {
+			t.Errorf("the document has no %s %s", method, path)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package employee
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// A Balance is how much leave an employee has, as data a program can use.
+// Render turns it into text for people.
+type Balance struct {
+	EmployeeID string `json:"employeeId"`
+	Name       string `json:"name"`
+	// Total, Taken, Pending and Remaining are a summary of all the leave types which are not unlimited.
+	// The amounts are numbers of days in JSON, such as 2.5
+	Total Amount `json:"total"`
+	Taken Amount `json:"taken"`
+	// Pending is the leave asked for in requests which are not decided yet, it is only filled in by Leaves.Balance
+	Pending Amount `json:"pending"`
+	// Remaining is Total - Taken, pending leave is not subtracted
+	Remaining Amount `json:"remaining"`
+	// ByType has a line for annual leave and for every other type of leave the employee has, has taken or has asked for
+	ByType []TypeBalance `json:"byType"`
+}
+
+// A TypeBalance is the leave of one type an employee has, has taken and has asked for.
+type TypeBalance struct {
+	Type LeaveType `json:"type"`
+	// Unlimited types have no Total or Remaining, see TypeRules
+	Unlimited bool   `json:"unlimited,omitempty"`
+	Total     Amount `json:"total"`
+	Taken     Amount `json:"taken"`
+	Pending   Amount `json:"pending"`
+	Remaining Amount `json:"remaining"`
+}
+
+// Balance returns the employee's leave balance.
+func (e employee) Balance() Balance {
+	return e.balance([numLeaveTypes]Amount{})
+}
+
+// balance returns the employee's leave balance, with pending leave of each type in the order of leaveTypes
+func (e employee) balance(pending [numLeaveTypes]Amount) Balance {
+	b := Balance{EmployeeID: e.id, Name: e.firstName + " " + e.lastName}
+	for i, t := range leaveTypes {
+		a := e.leave[i]
+		tb := TypeBalance{Type: t, Unlimited: RulesFor(t).Unlimited, Total: a.Total, Taken: a.Taken, Pending: pending[i], Remaining: a.Remaining()}
+		if tb.Unlimited {
+			tb.Total, tb.Remaining = 0, 0
+		} else {
+			b.Total += tb.Total
+			b.Taken += tb.Taken
+			b.Pending += tb.Pending
+			b.Remaining += tb.Remaining
+		}
+		if t == Annual || tb.Total != 0 || tb.Taken != 0 || tb.Pending != 0 {
+			b.ByType = append(b.ByType, tb)
+		}
+	}
+	return b
+}
+
+// Balance returns the leave balance of the employee with the given id, including the leave in pending requests.
+func (l *Leaves) Balance(employeeID string) (Balance, error) {
+	e, err := l.repo.Get(employeeID)
+	if err != nil {
+		return Balance{}, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var pending [numLeaveTypes]Amount
+	for i, t := range leaveTypes {
+		pending[i] = l.pendingLeave(employeeID, t)
+	}
+	return e.value().balance(pending), nil
+}
+
+// Format is a way of rendering balances.
+type Format string
+
+const (
+	// Text is a sentence per employee, like the one LeavesRemaining prints
+	Text Format = "text"
+	// JSON is a JSON array of balances
+	JSON Format = "json"
+	// Table is a table with a row per employee, followed by a row per leave type
+	Table Format = "table"
+)
+
+// ParseFormat returns the Format named s, for reading it from a flag.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Text, JSON, Table:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q, want text, json or table", s)
+}
+
+// Render writes the balances to w in format f.
+func Render(w io.Writer, f Format, balances ...Balance) error {
+	switch f {
+	case Text:
+		return renderText(w, balances)
+	case JSON:
+		if balances == nil {
+			// an empty array rather than null
+			balances = []Balance{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(balances)
+	case Table:
+		return renderTable(w, balances)
+	}
+	return fmt.Errorf("unknown format %q", f)
+}
+
+func renderText(w io.Writer, balances []Balance) error {
+	for _, b := range balances {
+		if _, err := fmt.Fprintf(w, "%s has %s leaves remaining", b.Name, b.Remaining); err != nil {
+			return err
+		}
+		if b.Pending > 0 {
+			if _, err := fmt.Fprintf(w, ", %s pending approval", b.Pending); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderTable(w io.Writer, balances []Balance) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTOTAL\tTAKEN\tPENDING\tREMAINING")
+	for _, b := range balances {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", b.Name, b.Total, b.Taken, b.Pending, b.Remaining)
+		for _, t := range b.ByType {
+			if t.Unlimited {
+				fmt.Fprintf(tw, "  %s\t-\t%s\t%s\t-\n", t.Type, t.Taken, t.Pending)
+			} else {
+				fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", t.Type, t.Total, t.Taken, t.Pending, t.Remaining)
+			}
+		}
+	}
+	return tw.Flush()
+}
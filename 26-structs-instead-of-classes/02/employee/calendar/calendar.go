@@ -0,0 +1,146 @@
+// Package calendar knows which days are working days: not weekends, and not public holidays.
+// Public holidays differ between regions, so every holiday belongs to a region,
+// and holidays added with the region "" are holidays everywhere.
+package calendar
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Holiday is a day nobody in its region works.
+type Holiday struct {
+	Date   time.Time
+	Name   string
+	Region string
+}
+
+// A Calendar holds the public holidays of any number of regions.
+// It is safe to use from several Goroutines, holidays can be loaded while it is in use.
+type Calendar struct {
+	mu      sync.RWMutex
+	weekend [7]bool
+	// holidays maps a region to its holidays, keyed by the day at midnight UTC
+	holidays map[string]map[time.Time]string
+}
+
+// New returns a calendar with a Saturday and Sunday weekend and no holidays.
+func New() *Calendar {
+	c := &Calendar{holidays: make(map[string]map[time.Time]string)}
+	c.SetWeekend(time.Saturday, time.Sunday)
+	return c
+}
+
+// SetWeekend sets the days of the week nobody works.
+func (c *Calendar) SetWeekend(days ...time.Weekday) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.weekend = [7]bool{}
+	for _, d := range days {
+		c.weekend[d] = true
+	}
+}
+
+// day drops the time of day from t, so holidays can be looked up by date
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Add adds a holiday to region.
+func (c *Calendar) Add(region string, date time.Time, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.holidays[region] == nil {
+		c.holidays[region] = make(map[time.Time]string)
+	}
+	c.holidays[region][day(date)] = name
+}
+
+// holiday returns the name of the holiday on date in region, the caller must hold mu
+func (c *Calendar) holiday(region string, date time.Time) (string, bool) {
+	date = day(date)
+	if name, ok := c.holidays[region][date]; ok {
+		return name, true
+	}
+	name, ok := c.holidays[""][date]
+	return name, ok
+}
+
+// IsWorkingDay reports whether date is a working day in region.
+func (c *Calendar) IsWorkingDay(region string, date time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.weekend[date.Weekday()] {
+		return false
+	}
+	_, ok := c.holiday(region, date)
+	return !ok
+}
+
+// WorkingDays returns the number of working days in region from from to to, both days included.
+func (c *Calendar) WorkingDays(region string, from, to time.Time) int {
+	n := 0
+	for d := day(from); !d.After(day(to)); d = d.AddDate(0, 0, 1) {
+		if c.IsWorkingDay(region, d) {
+			n++
+		}
+	}
+	return n
+}
+
+// Holidays returns the holidays in region from from to to, both days included, sorted by date.
+// Holidays which fall on a weekend are included too.
+// A day which is a holiday both in region and everywhere is listed once, by the name the region gives it.
+func (c *Calendar) Holidays(region string, from, to time.Time) []Holiday {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var hs []Holiday
+	for _, r := range []string{region, ""} {
+		for date, name := range c.holidays[r] {
+			if _, regional := c.holidays[region][date]; r == "" && region != "" && regional {
+				continue
+			}
+			if !date.Before(day(from)) && !date.After(day(to)) {
+				hs = append(hs, Holiday{Date: date, Name: name, Region: r})
+			}
+		}
+		if region == "" {
+			break
+		}
+	}
+	sort.Slice(hs, func(i, j int) bool {
+		if !hs[i].Date.Equal(hs[j].Date) {
+			return hs[i].Date.Before(hs[j].Date)
+		}
+		return hs[i].Region > hs[j].Region
+	})
+	return hs
+}
+
+// LoadFile adds the holidays in the file at path to region.
+// Files ending in .ics are read as iCalendar, see LoadICS, and files ending in .csv as CSV, see LoadCSV.
+func (c *Calendar) LoadFile(region string, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ics":
+		err = c.LoadICS(region, f)
+	case ".csv":
+		err = c.LoadCSV(region, f)
+	default:
+		return fmt.Errorf("%s: holidays must be in a .ics or .csv file", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// event wraps the properties of one VEVENT in a calendar
+func event(props ...string) string {
+	return "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Day off\r\n" + strings.Join(props, "\r\n") + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+}
+
+func TestLoadICS(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		ics   string
+		dates []string
+		err   string
+	}{
+		{name: "date", ics: event("DTSTART;VALUE=DATE:20241225"), dates: []string{"2024-12-25"}},
+		{name: "dates", ics: event("DTSTART;VALUE=DATE:20241225", "DTEND;VALUE=DATE:20241227"), dates: []string{"2024-12-25", "2024-12-26"}},
+		{name: "same day times", ics: event("DTSTART:20241224T090000Z", "DTEND:20241224T170000Z"), dates: []string{"2024-12-24"}},
+		{name: "time without end", ics: event("DTSTART;TZID=Europe/Berlin:20241224T090000"), dates: []string{"2024-12-24"}},
+		{name: "times over midnight", ics: event("DTSTART:20241224T220000Z", "DTEND:20241225T020000Z"), dates: []string{"2024-12-24", "2024-12-25"}},
+		{name: "times to midnight", ics: event("DTSTART:20241224T000000Z", "DTEND:20241226T000000Z"), dates: []string{"2024-12-24", "2024-12-25"}},
+		{name: "dates backwards", ics: event("DTSTART;VALUE=DATE:20241225", "DTEND;VALUE=DATE:20241225"), err: "ends before it starts"},
+		{name: "times backwards", ics: event("DTSTART:20241224T170000Z", "DTEND:20241224T090000Z"), err: "ends before it starts"},
+		{name: "date and time", ics: event("DTSTART;VALUE=DATE:20241224", "DTEND:20241224T170000Z"), err: "both be dates"},
+		{name: "bad date", ics: event("DTSTART:2024-12-24"), err: "invalid date"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			err := c.LoadICS("be", strings.NewReader(tt.ics))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got error %v, want one saying %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, h := range c.Holidays("be", date("2024-01-01"), date("2024-12-31")) {
+				got = append(got, h.Date.Format(time.DateOnly))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.dates, " ") {
+				t.Errorf("holidays on %v, want %v", got, tt.dates)
+			}
+		})
+	}
+}
+
+func TestHolidaysListsADayOnce(t *testing.T) {
+	c := New()
+	c.Add("", date("2024-12-25"), "Christmas Day")
+	c.Add("", date("2024-12-26"), "Boxing Day")
+	c.Add("ie", date("2024-12-26"), "St Stephen's Day")
+
+	hs := c.Holidays("ie", date("2024-12-24"), date("2024-12-31"))
+	want := []Holiday{
+		{Date: date("2024-12-25"), Name: "Christmas Day"},
+		{Date: date("2024-12-26"), Name: "St Stephen's Day", Region: "ie"},
+	}
+	if len(hs) != len(want) {
+		t.Fatalf("got %v, want %v", hs, want)
+	}
+	for i := range want {
+		if !hs[i].Date.Equal(want[i].Date) || hs[i].Name != want[i].Name || hs[i].Region != want[i].Region {
+			t.Errorf("holiday %d is %v, want %v", i, hs[i], want[i])
+		}
+	}
+	if n := len(c.Holidays("", date("2024-12-24"), date("2024-12-31"))); n != 2 {
+		t.Errorf("%d holidays everywhere, want 2", n)
+	}
+}
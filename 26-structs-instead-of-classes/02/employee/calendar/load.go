@@ -0,0 +1,162 @@
+package calendar
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// LoadCSV adds the holidays in r to region.
+// Every row is a date formatted as 2006-01-02 and the name of the holiday.
+// A first row of "date,name" is taken as a header and skipped.
+func (c *Calendar) LoadCSV(region string, r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+	var holidays []Holiday
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		if line == 1 && strings.EqualFold(row[0], "date") {
+			continue
+		}
+		date, err := time.Parse(time.DateOnly, row[0])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		holidays = append(holidays, Holiday{Date: date, Name: row[1]})
+	}
+	// nothing is added unless the whole file could be read
+	for _, h := range holidays {
+		c.Add(region, h.Date, h.Name)
+	}
+	return nil
+}
+
+// LoadICS adds the holidays in the iCalendar (RFC 5545) data in r to region, which is how most calendar programs export them.
+// Every VEVENT is a holiday named by its SUMMARY. An event of DATEs lasts from its DTSTART up to but not including its DTEND;
+// an event of DATE-TIMEs lasts every day it touches, so one from 09:00 to 17:00 is a holiday on that day.
+// Recurring events are not supported, since public holiday calendars list every year's dates anyway.
+func (c *Calendar) LoadICS(region string, r io.Reader) error {
+	lines, err := unfold(r)
+	if err != nil {
+		return err
+	}
+	var holidays []Holiday
+	var event map[string]string
+	for i, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		// parameters such as DTSTART;VALUE=DATE are not needed
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event = make(map[string]string)
+		case name == "END" && value == "VEVENT":
+			if event == nil {
+				return fmt.Errorf("line %d: END:VEVENT without BEGIN:VEVENT", i+1)
+			}
+			hs, err := eventHolidays(event)
+			if err != nil {
+				return fmt.Errorf("event ending on line %d: %w", i+1, err)
+			}
+			holidays = append(holidays, hs...)
+			event = nil
+		case event != nil:
+			event[name] = value
+		}
+	}
+	for _, h := range holidays {
+		c.Add(region, h.Date, h.Name)
+	}
+	return nil
+}
+
+// unfold reads the lines of iCalendar data, joining the lines which the format splits at 75 characters.
+// A line that starts with a space or a tab continues the one before it.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, s.Err()
+}
+
+// eventHolidays returns a holiday for every day of a VEVENT
+func eventHolidays(event map[string]string) ([]Holiday, error) {
+	if _, ok := event["RRULE"]; ok {
+		return nil, errors.New("recurring events are not supported")
+	}
+	start, isDate, err := parseICSDate(event["DTSTART"])
+	if err != nil {
+		return nil, fmt.Errorf("DTSTART: %w", err)
+	}
+	// without a DTEND an event of a DATE is that day, and an event of a DATE-TIME is that moment
+	end := start
+	if isDate {
+		end = start.AddDate(0, 0, 1)
+	}
+	if v, ok := event["DTEND"]; ok {
+		var endIsDate bool
+		if end, endIsDate, err = parseICSDate(v); err != nil {
+			return nil, fmt.Errorf("DTEND: %w", err)
+		}
+		if endIsDate != isDate {
+			return nil, errors.New("DTSTART and DTEND must both be dates or both be date-times")
+		}
+	}
+	// the times are compared before they are turned into days, so an event from 09:00 to 17:00 is fine
+	if end.Before(start) || (isDate && !end.After(start)) {
+		return nil, errors.New("event ends before it starts")
+	}
+
+	// a DATE DTEND is the day after the last one, while a DATE-TIME DTEND is on the last day,
+	// unless it is midnight, when the event stops as the day begins
+	last := day(end)
+	if isDate || (end.Equal(last) && end.After(start)) {
+		last = last.AddDate(0, 0, -1)
+	}
+	name := unescape(event["SUMMARY"])
+	var hs []Holiday
+	for d := day(start); !d.After(last); d = d.AddDate(0, 0, 1) {
+		hs = append(hs, Holiday{Date: d, Name: name})
+	}
+	return hs, nil
+}
+
+// parseICSDate parses a DATE such as 20241225, or a DATE-TIME such as 20241225T090000Z, reporting which it was.
+// A DATE-TIME in local time, with or without a TZID, is read as if it were UTC: only the day and the order of the times matter.
+func parseICSDate(v string) (t time.Time, isDate bool, err error) {
+	if len(v) == len("20060102") {
+		t, err = time.Parse("20060102", v)
+		return t, true, err
+	}
+	t, err = time.Parse("20060102T150405", strings.TrimSuffix(v, "Z"))
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date %q", v)
+	}
+	return t, false, nil
+}
+
+// unescape undoes the escaping of commas, semicolons, backslashes and newlines in iCalendar text
+func unescape(s string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\\`, `\`, `\n`, "\n", `\N`, "\n").Replace(s)
+}
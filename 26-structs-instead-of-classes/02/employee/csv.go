@@ -0,0 +1,424 @@
+package employee
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// A csvColumn is one column of the CSV form of a Record.
+// A field added to Record only needs a column here to be imported and exported.
+type csvColumn struct {
+	name string
+	get  func(r Record) string
+	// set parses a cell into r, it returns the problem with the cell if there is one
+	set func(r *Record, v string) string
+}
+
+// csvColumns are the columns in the order WriteCSV writes them.
+// New columns go at the end, so files written before they were added still line up.
+var csvColumns = func() []csvColumn {
+	text := func(name string, field func(r *Record) *string) csvColumn {
+		return csvColumn{
+			name: name,
+			get:  func(r Record) string { return *field(&r) },
+			set:  func(r *Record, v string) string { *field(r) = v; return "" },
+		}
+	}
+	amount := func(name string, field func(r *Record) *Amount) csvColumn {
+		return csvColumn{
+			name: name,
+			get:  func(r Record) string { return field(&r).String() },
+			set: func(r *Record, v string) string {
+				a, problem := parseCell(v)
+				*field(r) = a
+				return problem
+			},
+		}
+	}
+	cols := []csvColumn{
+		text("id", func(r *Record) *string { return &r.ID }),
+		text("firstName", func(r *Record) *string { return &r.FirstName }),
+		text("lastName", func(r *Record) *string { return &r.LastName }),
+		amount("totalLeaves", func(r *Record) *Amount { return &r.TotalLeaves }),
+		amount("leavesTaken", func(r *Record) *Amount { return &r.LeavesTaken }),
+		text("startDate", func(r *Record) *string { return &r.StartDate }),
+		text("region", func(r *Record) *string { return &r.Region }),
+	}
+	// the other leave types are named the way validate names them, such as sick.total and sick.taken
+	for _, t := range leaveTypes[1:] {
+		for _, total := range []bool{true, false} {
+			field := func(a *Allowance) *Amount {
+				if total {
+					return &a.Total
+				}
+				return &a.Taken
+			}
+			name := string(t) + ".taken"
+			if total {
+				name = string(t) + ".total"
+			}
+			cols = append(cols, csvColumn{
+				name: name,
+				get:  func(r Record) string { a := r.Leave[t]; return field(&a).String() },
+				set: func(r *Record, v string) string {
+					a := r.Leave[t]
+					n, problem := parseCell(v)
+					*field(&a) = n
+					// Record only lists the types the employee has or has taken
+					if a != (Allowance{}) {
+						if r.Leave == nil {
+							r.Leave = make(map[LeaveType]Allowance)
+						}
+						r.Leave[t] = a
+					}
+					return problem
+				},
+			})
+		}
+	}
+	cols = append(cols,
+		csvColumn{
+			name: "pay.basis",
+			get: func(r Record) string {
+				if r.Pay == nil {
+					return ""
+				}
+				return string(r.Pay.Basis)
+			},
+			set: func(r *Record, v string) string {
+				if v != "" {
+					r.Pay = payOf(r)
+					r.Pay.Basis = PayBasis(strings.ToLower(v))
+				}
+				return ""
+			},
+		},
+		csvColumn{
+			name: "pay.rate",
+			get: func(r Record) string {
+				if r.Pay == nil {
+					return ""
+				}
+				return r.Pay.Rate.String()
+			},
+			set: func(r *Record, v string) string {
+				if v == "" {
+					return ""
+				}
+				c, err := ParseCents(v)
+				if err != nil {
+					return "must be an amount of money such as 52000 or 31.50"
+				}
+				r.Pay = payOf(r)
+				r.Pay.Rate = c
+				return ""
+			},
+		},
+	)
+	return cols
+}()
+
+// payOf returns the Pay of r, adding one if it has none
+func payOf(r *Record) *Pay {
+	if r.Pay == nil {
+		r.Pay = &Pay{}
+	}
+	return r.Pay
+}
+
+// parseCell parses an amount of leave in a cell, which is 0 if the cell is empty
+func parseCell(v string) (Amount, string) {
+	if v == "" {
+		return 0, ""
+	}
+	a, err := ParseAmount(v)
+	if err != nil {
+		return 0, "must be an amount of leave such as 2.5, 2.5d or 3h"
+	}
+	return a, ""
+}
+
+// CSVColumns returns the names of the columns WriteCSV writes, in the order it writes them.
+func CSVColumns() []string {
+	names := make([]string, len(csvColumns))
+	for i, c := range csvColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// WriteCSV writes the records to w as CSV, with a header row and every column of CSVColumns in the same order each time,
+// so exports of the same employees can be compared line by line.
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVColumns()); err != nil {
+		return err
+	}
+	row := make([]string, len(csvColumns))
+	for _, r := range records {
+		for i, c := range csvColumns {
+			row[i] = c.get(r)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// A RowError is what is wrong with one row of a CSV file.
+type RowError struct {
+	// Line is the line of the file the row starts on, the header is line 1
+	Line int
+	Err  error
+}
+
+func (r *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", r.Line, r.Err)
+}
+
+func (r *RowError) Unwrap() error {
+	return r.Err
+}
+
+// A CSVError is returned by ReadCSV and ImportCSV when some rows are not valid.
+// It lists every one of them, so a spreadsheet can be fixed in one go.
+type CSVError struct {
+	Rows []*RowError
+}
+
+func (c *CSVError) Error() string {
+	problems := make([]string, len(c.Rows))
+	for i, r := range c.Rows {
+		problems[i] = r.Error()
+	}
+	return fmt.Sprintf("%d invalid rows: %s", len(c.Rows), strings.Join(problems, "; "))
+}
+
+// CSVOptions are how ImportCSV reads a file.
+type CSVOptions struct {
+	// Headers maps headers of the file to the columns of CSVColumns they hold, such as "Surname" to "lastName".
+	// Headers which are not in it are matched to the columns ignoring case, spaces and punctuation,
+	// so "First Name" and "first_name" are both firstName.
+	Headers map[string]string
+	// Ignore lists headers whose columns are skipped, any other header that doesn't match a column is an error
+	Ignore []string
+	// DryRun checks the file and the repository without creating anybody
+	DryRun bool
+}
+
+// ReadCSV reads records from CSV with a header row, see CSVOptions for how the headers are matched to columns.
+// Columns can be in any order and left out, except firstName and lastName. Empty amounts are 0.
+// Every row is checked like New checks its arguments, and if any are not valid ReadCSV returns a *CSVError listing them.
+func ReadCSV(r io.Reader, opts CSVOptions) ([]Record, error) {
+	rows, err := readCSV(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, len(rows))
+	for i, row := range rows {
+		records[i] = row.record
+	}
+	return records, nil
+}
+
+// csvRow is a record read from CSV and the line it was on
+type csvRow struct {
+	line   int
+	record Record
+}
+
+func readCSV(r io.Reader, opts CSVOptions) ([]csvRow, error) {
+	// spreadsheets often start their CSV with a byte order mark, which would be taken as part of the first header
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(3); err == nil && string(b) == "\ufeff" {
+		br.Discard(3)
+	}
+	cr := csv.NewReader(br)
+	// rows with the wrong number of cells are reported with the other row errors
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("line 1: no header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols, err := mapHeader(header, opts)
+	if err != nil {
+		return nil, fmt.Errorf("line 1: %w", err)
+	}
+
+	var rows []csvRow
+	var invalid []*RowError
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// a broken quote spoils the rest of the file, so there is no point reading on
+			invalid = append(invalid, &RowError{Line: parseErr.StartLine, Err: parseErr.Err})
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(row) != len(header) {
+			invalid = append(invalid, &RowError{Line: line, Err: fmt.Errorf("has %d cells, the header has %d", len(row), len(header))})
+			continue
+		}
+		rec, err := readRow(row, cols)
+		if err != nil {
+			invalid = append(invalid, &RowError{Line: line, Err: err})
+			continue
+		}
+		rows = append(rows, csvRow{line: line, record: rec})
+	}
+	if invalid != nil {
+		return nil, &CSVError{Rows: invalid}
+	}
+	return rows, nil
+}
+
+// mapHeader returns the column of every cell of the header, nil for the ones which are ignored
+func mapHeader(header []string, opts CSVOptions) ([]*csvColumn, error) {
+	byName := make(map[string]*csvColumn)
+	for i := range csvColumns {
+		byName[normalize(csvColumns[i].name)] = &csvColumns[i]
+	}
+	ignore := make(map[string]bool)
+	for _, h := range opts.Ignore {
+		ignore[normalize(h)] = true
+	}
+	mapped := make(map[string]string)
+	for h, name := range opts.Headers {
+		if byName[normalize(name)] == nil {
+			return nil, fmt.Errorf("header %q is mapped to %q, which is not a column", h, name)
+		}
+		mapped[normalize(h)] = name
+	}
+
+	cols := make([]*csvColumn, len(header))
+	seen := make(map[string]string)
+	var unknown []string
+	for i, h := range header {
+		key := normalize(h)
+		if ignore[key] {
+			continue
+		}
+		if name, ok := mapped[key]; ok {
+			key = normalize(name)
+		}
+		c := byName[key]
+		if c == nil {
+			unknown = append(unknown, fmt.Sprintf("%q", h))
+			continue
+		}
+		if other, ok := seen[c.name]; ok {
+			return nil, fmt.Errorf("headers %q and %q are both %s", other, h, c.name)
+		}
+		seen[c.name] = h
+		cols[i] = c
+	}
+	if unknown != nil {
+		return nil, fmt.Errorf("unknown headers %s, map them to one of %s or ignore them", strings.Join(unknown, ", "), strings.Join(CSVColumns(), ", "))
+	}
+	for _, required := range []string{"firstName", "lastName"} {
+		if _, ok := seen[required]; !ok {
+			return nil, fmt.Errorf("no %s column", required)
+		}
+	}
+	return cols, nil
+}
+
+// normalize returns a header without case, spaces and punctuation, so "First Name" matches firstName
+func normalize(h string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, h)
+}
+
+// readRow returns the record in row, checked like New checks an employee
+func readRow(row []string, cols []*csvColumn) (Record, error) {
+	var rec Record
+	var fields []FieldError
+	for i, c := range cols {
+		if c == nil {
+			continue
+		}
+		v := strings.TrimSpace(row[i])
+		if problem := c.set(&rec, v); problem != "" {
+			fields = append(fields, FieldError{Field: c.name, Value: fmt.Sprintf("%q", v), Problem: problem})
+		}
+	}
+	// the cells which could be read are checked too, so every problem with the row is reported at once
+	e, err := rec.Employee()
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		fields = append(fields, invalid.Fields...)
+	} else if err != nil {
+		return Record{}, err
+	}
+	if fields != nil {
+		return Record{}, &ValidationError{Fields: fields}
+	}
+	// keep the id a row without one was given, so a dry run shows the ids the employees would get
+	return e.Record(), nil
+}
+
+// ImportCSV creates an employee in repo for every row of r, read as ReadCSV reads it.
+// Either every row is created or none is: the file is checked first, and rows with the id of an employee
+// who is already in repo, or of an earlier row, are reported as a *CSVError along with the invalid ones.
+// With opts.DryRun the rows are checked but not created.
+// It returns the records as they were created, or would have been.
+func ImportCSV(repo Repository, r io.Reader, opts CSVOptions) ([]Record, error) {
+	rows, err := readCSV(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	var invalid []*RowError
+	seen := make(map[string]int)
+	for _, l := range rows {
+		if first, ok := seen[l.record.ID]; ok {
+			invalid = append(invalid, &RowError{Line: l.line, Err: fmt.Errorf("id %s is also on line %d: %w", l.record.ID, first, ErrExists)})
+			continue
+		}
+		seen[l.record.ID] = l.line
+		if _, err := repo.Get(l.record.ID); err == nil {
+			invalid = append(invalid, &RowError{Line: l.line, Err: fmt.Errorf("id %s: %w", l.record.ID, ErrExists)})
+		} else if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+	if invalid != nil {
+		return nil, &CSVError{Rows: invalid}
+	}
+
+	created := make([]Record, len(rows))
+	for i, l := range rows {
+		created[i] = l.record
+		if opts.DryRun {
+			continue
+		}
+		e, _ := l.record.Employee()
+		if e, err = repo.Create(e); err != nil {
+			// someone else added the same id since we checked
+			return nil, fmt.Errorf("line %d: %w, %d rows were created before it", l.line, err, i)
+		}
+		created[i] = e.Record()
+	}
+	return created, nil
+}
@@ -0,0 +1,286 @@
+package employee
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSVHeader(t *testing.T) {
+	ada := Record{ID: "ada", FirstName: "Ada", LastName: "Lovelace", TotalLeaves: Days(25)}
+	for _, tt := range []struct {
+		name string
+		csv  string
+		opts CSVOptions
+		want []Record
+		// err is the error ReadCSV must return instead
+		err string
+	}{
+		{
+			name: "column names",
+			csv:  "id,firstName,lastName,totalLeaves\nada,Ada,Lovelace,25\n",
+			want: []Record{ada},
+		},
+		{
+			name: "headers are matched ignoring case, spaces and punctuation",
+			csv:  "ID,First Name,LAST_NAME,total-leaves\nada,Ada,Lovelace,25\n",
+			want: []Record{ada},
+		},
+		{
+			name: "columns in any order",
+			csv:  "totalLeaves,lastName,id,firstName\n25,Lovelace,ada,Ada\n",
+			want: []Record{ada},
+		},
+		{
+			name: "mapped headers",
+			csv:  "id,Given,Surname,Days\nada,Ada,Lovelace,25\n",
+			opts: CSVOptions{Headers: map[string]string{"given": "firstName", "Surname": "last name", "Days": "totalLeaves"}},
+			want: []Record{ada},
+		},
+		{
+			name: "ignored headers",
+			csv:  "id,firstName,lastName,totalLeaves,Notes,Manager\nada,Ada,Lovelace,25,likes engines,\n",
+			opts: CSVOptions{Ignore: []string{"notes", "MANAGER"}},
+			want: []Record{ada},
+		},
+		{
+			// the quote would be a bare quote in the middle of a cell if the mark were left in front of it
+			name: "byte order mark",
+			csv:  "\ufeff\"id\",firstName,lastName,totalLeaves\nada,Ada,Lovelace,25\n",
+			want: []Record{ada},
+		},
+		{
+			name: "no rows",
+			csv:  "firstName,lastName\n",
+			want: []Record{},
+		},
+		{
+			name: "unknown headers",
+			csv:  "id,firstName,lastName,Notes,Manager\n",
+			opts: CSVOptions{Ignore: []string{"Manager"}},
+			err:  `line 1: unknown headers "Notes", map them to one of ` + strings.Join(CSVColumns(), ", ") + " or ignore them",
+		},
+		{
+			name: "duplicate headers",
+			csv:  "First Name,firstName,lastName\n",
+			err:  `line 1: headers "First Name" and "firstName" are both firstName`,
+		},
+		{
+			name: "header mapped onto another",
+			csv:  "Surname,lastName,firstName\n",
+			opts: CSVOptions{Headers: map[string]string{"Surname": "lastName"}},
+			err:  `line 1: headers "Surname" and "lastName" are both lastName`,
+		},
+		{
+			name: "header mapped to no column",
+			csv:  "firstName,lastName,Surname\n",
+			opts: CSVOptions{Headers: map[string]string{"Surname": "family"}},
+			err:  `line 1: header "Surname" is mapped to "family", which is not a column`,
+		},
+		{
+			name: "missing required column",
+			csv:  "id,firstName\n",
+			err:  "line 1: no lastName column",
+		},
+		{
+			name: "empty file",
+			csv:  "",
+			err:  "line 1: no header row",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := ReadCSV(strings.NewReader(tt.csv), tt.opts)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(records, tt.want) {
+				t.Errorf("read %+v, want %+v", records, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSVRows(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		csv  string
+		// rows are the lines and errors of the invalid rows
+		rows []RowError
+	}{
+		{
+			name: "wrong number of cells",
+			csv:  "id,firstName,lastName\nada,Ada,Lovelace\ngrace,Grace\nalan,Alan,Turing,extra\n",
+			rows: []RowError{
+				{Line: 3, Err: errors.New("has 2 cells, the header has 3")},
+				{Line: 4, Err: errors.New("has 4 cells, the header has 3")},
+			},
+		},
+		{
+			name: "every problem with a row",
+			csv:  "firstName,lastName,totalLeaves,leavesTaken,sick.total\n,Lovelace,lots,2,-1\n",
+			rows: []RowError{{Line: 2, Err: errors.New(`invalid employee: totalLeaves "lots": must be an amount of leave such as 2.5, 2.5d or 3h; ` +
+				`firstName "": must not be empty; leavesTaken 2: must not be more than totalLeaves 0; sick.total -1: must not be negative`)}},
+		},
+		{
+			name: "line of a row after a cell over several lines",
+			csv:  "firstName,lastName,totalLeaves\n\"Ada\nAugusta\",Lovelace,25\nGrace,Hopper,x\n",
+			rows: []RowError{{Line: 4, Err: errors.New(`invalid employee: totalLeaves "x": must be an amount of leave such as 2.5, 2.5d or 3h`)}},
+		},
+		{
+			name: "row starting with a cell over several lines",
+			csv:  "firstName,lastName,totalLeaves\nGrace,Hopper,x\n\"Ada\nAugusta\",Lovelace,\"2\n5\"\n",
+			rows: []RowError{
+				{Line: 2, Err: errors.New(`invalid employee: totalLeaves "x": must be an amount of leave such as 2.5, 2.5d or 3h`)},
+				{Line: 3, Err: errors.New(`invalid employee: totalLeaves "2\n5": must be an amount of leave such as 2.5, 2.5d or 3h`)},
+			},
+		},
+		{
+			// the rows after the broken quote are not read, they would only be reported as more mistakes
+			name: "broken quote",
+			csv:  "firstName,lastName\nGrace,\nAda,\"Lovelace\nAlan,Turing\n",
+			rows: []RowError{
+				{Line: 2, Err: errors.New(`invalid employee: lastName "": must not be empty`)},
+				{Line: 3, Err: errors.New(`extraneous or missing " in quoted-field`)},
+			},
+		},
+		{
+			name: "quote in an unquoted cell",
+			csv:  "firstName,lastName\nAda,Love\"lace\n",
+			rows: []RowError{{Line: 2, Err: errors.New(`bare " in non-quoted-field`)}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadCSV(strings.NewReader(tt.csv), CSVOptions{})
+			var invalid *CSVError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("got %v, want a *CSVError", err)
+			}
+			if len(invalid.Rows) != len(tt.rows) {
+				t.Fatalf("got %d invalid rows, want %d: %v", len(invalid.Rows), len(tt.rows), err)
+			}
+			for i, want := range tt.rows {
+				if got := invalid.Rows[i]; got.Line != want.Line || got.Err.Error() != want.Err.Error() {
+					t.Errorf("invalid row %d is %v, want %v", i, got, &want)
+				}
+			}
+		})
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	const file = "id,firstName,lastName,totalLeaves\nada,Ada,Lovelace,25\ngrace,Grace,Hopper,30\n"
+	for _, tt := range []struct {
+		name string
+		// existing are the employees already in the repository
+		existing []string
+		csv      string
+		dryRun   bool
+		// rows are the lines and errors of the rows which can't be imported
+		rows []RowError
+	}{
+		{name: "import", csv: file},
+		{name: "dry run", csv: file, dryRun: true},
+		{
+			name: "duplicate ids in the file",
+			csv:  file + "ada,Ada,Byron,20\nalan,Alan,Turing,28\ngrace,Grace,Murray,\n",
+			rows: []RowError{
+				{Line: 4, Err: errors.New("id ada is also on line 2: employee already exists")},
+				{Line: 6, Err: errors.New("id grace is also on line 3: employee already exists")},
+			},
+		},
+		{
+			name:     "ids already in the repository",
+			existing: []string{"grace"},
+			csv:      file,
+			rows:     []RowError{{Line: 3, Err: errors.New("id grace: employee already exists")}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			for _, id := range tt.existing {
+				e, err := New("Someone", "Else", 0, 0, WithID(id))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := repo.Create(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			records, err := ImportCSV(repo, strings.NewReader(tt.csv), CSVOptions{DryRun: tt.dryRun})
+			all, listErr := repo.List()
+			if listErr != nil {
+				t.Fatal(listErr)
+			}
+			if tt.rows != nil {
+				var invalid *CSVError
+				if !errors.As(err, &invalid) || !errors.Is(invalid.Rows[0], ErrExists) {
+					t.Fatalf("got %v, want a *CSVError for existing ids", err)
+				}
+				if len(invalid.Rows) != len(tt.rows) {
+					t.Fatalf("got %d invalid rows, want %d: %v", len(invalid.Rows), len(tt.rows), err)
+				}
+				for i, want := range tt.rows {
+					if got := invalid.Rows[i]; got.Line != want.Line || got.Err.Error() != want.Err.Error() {
+						t.Errorf("invalid row %d is %v, want %v", i, got, &want)
+					}
+				}
+				// none of the rows is created, not even the ones which are fine
+				if len(all) != len(tt.existing) {
+					t.Errorf("%d employees in the repository, want the %d already there", len(all), len(tt.existing))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 2 || records[0].ID != "ada" || records[1].ID != "grace" {
+				t.Errorf("imported %+v, want ada and grace", records)
+			}
+			want := 2
+			if tt.dryRun {
+				want = 0
+			}
+			if len(all) != want {
+				t.Errorf("%d employees in the repository, want %d", len(all), want)
+			}
+		})
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	records := []Record{
+		{
+			ID: "ada", FirstName: "Ada", LastName: "Lovelace", TotalLeaves: Days(25), LeavesTaken: Days(2) + HalfDay,
+			StartDate: "2024-01-15", Region: "GB",
+			Leave: map[LeaveType]Allowance{Sick: {Total: Days(10), Taken: Hours(3)}, Compensatory: {Total: Day}},
+			Pay:   &Pay{Basis: Hourly, Rate: 3150},
+		},
+		{
+			ID: "grace", FirstName: "Grace", LastName: "Hopper, Rear Admiral", TotalLeaves: Days(30),
+			Leave: map[LeaveType]Allowance{Parental: {Total: Days(90)}, Unpaid: {Taken: Days(4)}},
+			Pay:   &Pay{Basis: Salaried, Rate: 5200000},
+		},
+		// nothing but the names
+		{ID: "alan", FirstName: "Alan", LastName: `Turing "the" Enigma`},
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadCSV(&buf, CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("read back\n%+v\nwant\n%+v", got, records)
+	}
+}
@@ -1,7 +1,10 @@
 package employee
 
 import (
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"time"
 )
 
 // In other OOP languages like java, this problem can be solved by using constructors.
@@ -22,19 +25,81 @@ import (
 // By doing so we have successfully unexported the employee struct and prevented access from other packages
 // It’s a good practice to make all fields of an unexported struct to be unexported too unless there is a specific need to export them
 type employee struct {
-	firstName   string
-	lastName    string
-	totalLeaves int
-	leavesTaken int
+	// id tells two employees with the same name apart, and stays the same when the employee is renamed
+	id string
+	// version is set by a Repository and goes up by one on every update, see repository.go
+	version   int
+	firstName string
+	lastName  string
+	// leave holds an Allowance for every leave type, in the order of leaveTypes.
+	// It is an array rather than a map so that copying an employee copies its leave too, see leavetype.go
+	leave [numLeaveTypes]Allowance
+	// startDate is the day the employee joined, zero if it is not known
+	startDate time.Time
+	// region decides which public holidays the employee has, see the calendar package
+	region string
+	// pay is the zero Pay until it is set with WithPay, see pay.go
+	pay Pay
+	// leaveDays is the leave taken through TakeLeave day by day, oldest first, see Leaves.Taken
+	leaveDays []LeaveDay
+	// changes are the changes made by methods such as Rename since the employee was read from a Repository, see handle.go
+	changes []Event
+	// problems are the options given to New which could not be applied, validate reports them with the rest
+	problems []FieldError
 }
 
-// Now since employee is unexported, it’s not possible to create values of type Employee from other packages
-// Hence we are providing an exported New function which takes the required parameters as input and returns a newly created employee.
-func New(firstName string, lastName string, totalLeave int, leavesTaken int) employee {
-	e := employee{firstName, lastName, totalLeave, leavesTaken}
-	return e
+// Now since employee is unexported, it’s not possible to create values of type employee from other packages
+// Hence we are providing an exported New function which takes the required parameters as input and creates the employee.
+// Other packages can't name the type employee either, so New returns it as an Employee,
+// an exported interface whose methods are the only way to read or change it, see handle.go.
+// New also checks the parameters, so an employee with an empty name or more leaves taken than it has can't be created either.
+// If they are not valid it returns a nil Employee and a *ValidationError listing every field that is wrong, see validate.go.
+// Optional fields are set with Options such as WithStartDate.
+// totalLeave and leavesTaken are whole days of annual leave, use WithLeave for parts of days and WithAllowance for other types of leave.
+func New(firstName string, lastName string, totalLeave int, leavesTaken int, opts ...Option) (Employee, error) {
+	e := employee{id: newID(), firstName: firstName, lastName: lastName}
+	*e.allowance(Annual) = Allowance{Total: Days(totalLeave), Taken: Days(leavesTaken)}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+	return e, nil
 }
 
+// newID returns a random 128 bit id, formatted like a UUID
+func newID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// ID returns the employee's unique id.
+func (e employee) ID() string {
+	return e.id
+}
+
+// Version returns the version of the employee last read from or written to a Repository, 0 if it has never been stored.
+func (e employee) Version() int {
+	return e.version
+}
+
+// StartDate returns the day the employee joined, zero if it is not known.
+func (e employee) StartDate() time.Time {
+	return e.startDate
+}
+
+// Region returns the region whose public holidays the employee has.
+func (e employee) Region() string {
+	return e.region
+}
+
+// LeavesRemaining prints how many leaves the employee has left, of all types together.
+// Use Balance to get the numbers instead, and Render to print them in other formats.
 func (e employee) LeavesRemaining() {
-	fmt.Printf("%s %s has %d leaves remaining\n", e.firstName, e.lastName, (e.totalLeaves - e.leavesTaken))
+	Render(os.Stdout, Text, e.Balance())
 }
@@ -0,0 +1,345 @@
+package employee_test
+
+import (
+	"errors"
+	"fmt"
+	"oop/employee"
+	"oop/employee/calendar"
+	"oop/employee/org"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func ExampleNew() {
+	e, err := employee.New("Sam", "Adolf", 30, 20)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	e.LeavesRemaining()
+
+	// New refuses to create an employee that isn't usable, and lists everything that is wrong with it.
+	// errors.As finds the *ValidationError so the program can look at the fields one by one.
+	_, err = employee.New("", "Adolf", 30, 40)
+	var invalid *employee.ValidationError
+	if errors.As(err, &invalid) {
+		for _, f := range invalid.Fields {
+			fmt.Println("invalid", f.Field+":", f.Problem)
+		}
+	}
+	// Output:
+	// Sam Adolf has 10 leaves remaining
+	// invalid firstName: must not be empty
+	// invalid leavesTaken: must not be more than totalLeaves 30
+}
+
+func ExampleNewMemoryRepository() {
+	repo := employee.NewMemoryRepository()
+	e, _ := employee.New("Sam", "Adolf", 30, 20, employee.WithID("sam"))
+	stored, err := repo.Create(e)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("stored with version", stored.Version())
+
+	// Two copies of the same employee are read, and both are updated.
+	// The first update wins and the second one finds that the employee has changed since it was read.
+	first, _ := repo.Get("sam")
+	second, _ := repo.Get("sam")
+	updated, err := repo.Update(first)
+	fmt.Println("first update:", updated.Version(), err)
+	_, err = repo.Update(second)
+	var conflict *employee.ConflictError
+	if errors.As(err, &conflict) {
+		fmt.Println("second update:", err)
+	}
+	// Output:
+	// stored with version 1
+	// first update: 2 <nil>
+	// second update: employee sam was changed by someone else: have version 1, stored version is 2
+}
+
+func ExampleNewFileRepository() {
+	dir, err := os.MkdirTemp("", "employees")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "employees.json")
+
+	// The file repository works like the memory one, but the employees are still there when the program is run again
+	e, _ := employee.New("Sam", "Adolf", 30, 20, employee.WithID("sam"))
+	if _, err := employee.NewFileRepository(path).Create(e); err != nil {
+		fmt.Println(err)
+		return
+	}
+	again := employee.NewFileRepository(path)
+	sam, _ := again.Get("sam")
+	sam.LeavesRemaining()
+	_, err = again.Create(e)
+	fmt.Println(err)
+	// Output:
+	// Sam Adolf has 10 leaves remaining
+	// employee already exists
+}
+
+func ExampleLeaves() {
+	repo := employee.NewMemoryRepository()
+	e, _ := employee.New("Sam", "Adolf", 30, 20, employee.WithID("sam"))
+	repo.Create(e)
+
+	// Sam asks for leave and the manager, Jane, decides. Approved leave is taken from Sam's balance.
+	leaves := employee.NewLeaves(repo)
+	holiday, _ := leaves.Submit("sam", employee.Annual, date("2024-07-01"), date("2024-07-05"), "summer holiday")
+	if _, err := leaves.Approve(holiday.ID, "jane"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	sam, _ := repo.Get("sam")
+	sam.LeavesRemaining()
+	// 5 days are left, so 7 more can't be taken
+	_, err := leaves.Submit("sam", employee.Annual, date("2024-12-23"), date("2024-12-29"), "christmas")
+	fmt.Println(err)
+	unpaid, _ := leaves.Submit("sam", employee.Unpaid, date("2024-09-10"), date("2024-09-10"), "")
+	leaves.Reject(unpaid.ID, "jane", "that is the day of the release")
+	for _, a := range leaves.History() {
+		fmt.Println(strings.TrimSpace(fmt.Sprintf("request %s %s by %s %s", a.RequestID, a.Action, a.By, a.Note)))
+	}
+	// Output:
+	// Sam Adolf has 5 leaves remaining
+	// employee sam asked for 7 days of annual leave but has 5 remaining
+	// request 1 pending by sam summer holiday
+	// request 1 approved by jane
+	// request 2 pending by sam
+	// request 2 rejected by jane that is the day of the release
+}
+
+func ExampleRender() {
+	repo := employee.NewMemoryRepository()
+	e, _ := employee.New("Sam", "Adolf", 30, 25, employee.WithID("sam"))
+	repo.Create(e)
+	leaves := employee.NewLeaves(repo)
+
+	// Balance returns the numbers LeavesRemaining prints, so the program can use them
+	if b := e.Balance(); b.Remaining < employee.Days(10) {
+		fmt.Println("only", b.Remaining, "days left, time to plan the rest of the year")
+	}
+	// The balance from Leaves includes pending requests and a line per leave type
+	leaves.Submit("sam", employee.Annual, date("2024-10-14"), date("2024-10-15"), "")
+	b, _ := leaves.Balance("sam")
+	employee.Render(os.Stdout, employee.Text, b)
+	employee.Render(os.Stdout, employee.Table, b)
+	// Output:
+	// only 5 days left, time to plan the rest of the year
+	// Sam Adolf has 5 leaves remaining, 2 pending approval
+	// NAME       TOTAL  TAKEN  PENDING  REMAINING
+	// Sam Adolf  30     25     2        5
+	//   annual   30     25     2        5
+}
+
+func ExamplePolicies() {
+	repo := employee.NewMemoryRepository()
+	leaves := employee.NewLeaves(repo)
+
+	// How much leave an employee has can also be worked out from a Policy and their start date.
+	// Here the sales team earns 2.5 days a month, and everybody else gets 24 days a year,
+	// pro-rated in the year they join, with up to 5 unused days carried over until the end of March.
+	policies := employee.Policies{
+		Default: employee.Rules{Accrual: employee.YearlyGrant{Leave: employee.Days(24), ProRate: true}, MaxCarryOver: employee.Days(5), CarryOverExpiry: 3},
+		Teams: map[string]employee.Policy{
+			"sales": employee.Rules{Accrual: employee.Monthly{Leave: employee.Days(2) + employee.HalfDay}, MaxCarryOver: employee.UnlimitedCarryOver},
+		},
+	}
+	for _, team := range []string{"engineering", "sales"} {
+		ada, _ := employee.New("Ada", "Lovelace", 0, 0, employee.WithID("ada-"+team), employee.WithStartDate(date("2024-04-15")))
+		repo.Create(ada)
+		ada, err := leaves.Accrue(ada.ID(), employee.Annual, policies.For(team), date("2024-10-18"))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("in %s: ", team)
+		ada.LeavesRemaining()
+	}
+	// Output:
+	// in engineering: Ada Lovelace has 18 leaves remaining
+	// in sales: Ada Lovelace has 15 leaves remaining
+}
+
+func ExampleLeaves_SubmitPart() {
+	repo := employee.NewMemoryRepository()
+	leaves := employee.NewLeaves(repo)
+
+	// Leave is counted in quarter hours, so Ada can take an afternoon off, or just 3 hours
+	ada, _ := employee.New("Ada", "Lovelace", 15, 0, employee.WithID("ada"))
+	repo.Create(ada)
+	for _, part := range []employee.Amount{employee.HalfDay, employee.Hours(3)} {
+		r, _ := leaves.SubmitPart("ada", employee.Annual, date("2024-10-21"), part, "")
+		leaves.Approve(r.ID, "jane")
+	}
+	ada, _ = repo.Get("ada")
+	ada.LeavesRemaining()
+	left := ada.Balance().Remaining
+	three, _ := employee.ParseAmount("3h")
+	fmt.Printf("%s days rounded down to half days, 3h = %s days\n", left.Round(employee.HalfDay, employee.RoundDown), three)
+	// Output:
+	// Ada Lovelace has 14.125 leaves remaining
+	// 14 days rounded down to half days, 3h = 0.375 days
+}
+
+func ExampleWithAllowance() {
+	repo := employee.NewMemoryRepository()
+	leaves := employee.NewLeaves(repo)
+
+	// Every type of leave has an allowance of its own. LeavesRemaining sums them up, and the table shows each of them.
+	// Compensatory leave is granted for working overtime, and unpaid leave has no allowance.
+	grace, _ := employee.New("Grace", "Hopper", 25, 0, employee.WithID("grace"), employee.WithAllowance(employee.Sick, employee.Days(10), 0))
+	repo.Create(grace)
+	leaves.Grant("grace", employee.Compensatory, employee.Days(2))
+	for _, typ := range []employee.LeaveType{employee.Sick, employee.Unpaid, employee.Compensatory} {
+		r, _ := leaves.Submit("grace", typ, date("2024-11-04"), date("2024-11-05"), "")
+		leaves.Approve(r.ID, "jane")
+	}
+	_, err := leaves.Submit("grace", employee.Parental, date("2024-12-02"), date("2024-12-02"), "")
+	fmt.Println(err)
+	_, err = leaves.SubmitPart("grace", employee.Parental, date("2024-12-02"), employee.HalfDay, "")
+	fmt.Println(err)
+	grace, _ = repo.Get("grace")
+	grace.LeavesRemaining()
+	employee.Render(os.Stdout, employee.Table, grace.Balance())
+	// Output:
+	// employee grace asked for 1 days of parental leave but has 0 remaining
+	// parental leave must be taken in whole days
+	// Grace Hopper has 33 leaves remaining
+	// NAME            TOTAL  TAKEN  PENDING  REMAINING
+	// Grace Hopper    37     4      0        33
+	//   annual        25     0      0        25
+	//   sick          10     2      0        8
+	//   unpaid        -      2      0        -
+	//   compensatory  2      2      0        0
+}
+
+func ExampleLeaves_UseCalendar() {
+	repo := employee.NewMemoryRepository()
+	leaves := employee.NewLeaves(repo)
+
+	// With a calendar, only working days are taken off: weekends and the public holidays of the employee's region are free.
+	cal := calendar.New()
+	for region, file := range map[string]string{"uk": "../holidays/uk.csv", "de-by": "../holidays/de-by.ics"} {
+		if err := cal.LoadFile(region, file); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	leaves.UseCalendar(cal)
+	for _, region := range []string{"uk", "de-by"} {
+		linus, _ := employee.New("Linus", "Torvalds", 25, 0, employee.WithID("linus-"+region), employee.WithRegion(region))
+		repo.Create(linus)
+		r, _ := leaves.Submit(linus.ID(), employee.Annual, date("2024-12-23"), date("2025-01-06"), "")
+		fmt.Printf("%s: %d days off for %d working days of leave, holidays:", region, r.Days(), r.WorkingDays)
+		for _, h := range cal.Holidays(region, r.From, r.To) {
+			fmt.Printf(" %s", h.Name)
+		}
+		fmt.Println()
+	}
+	// Output:
+	// uk: 15 days off for 8 working days of leave, holidays: Christmas Day Boxing Day New Year's Day
+	// de-by: 15 days off for 7 working days of leave, holidays: Weihnachten Weihnachten Neujahr Heilige Drei Könige
+}
+
+func ExampleLeaves_UseChart() {
+	repo := employee.NewMemoryRepository()
+	leaves := employee.NewLeaves(repo)
+	chart := org.New()
+	for _, m := range []org.Member{
+		{ID: "jane", Name: "Jane Doe"},
+		{ID: "sam", Name: "Sam Adolf", ManagerID: "jane"},
+		{ID: "ada", Name: "Ada Lovelace", ManagerID: "jane"},
+	} {
+		chart.Add(m)
+	}
+
+	// With the chart, only Sam's managers can decide on Sam's leave
+	leaves.UseChart(chart)
+	sam, _ := employee.New("Sam", "Adolf", 30, 0, employee.WithID("sam"))
+	repo.Create(sam)
+	r, _ := leaves.Submit("sam", employee.Annual, date("2025-02-03"), date("2025-02-03"), "")
+	_, err := leaves.Approve(r.ID, "ada")
+	fmt.Println("ada:", err)
+	_, err = leaves.Approve(r.ID, "jane")
+	fmt.Println("jane:", err)
+	// Output:
+	// ada: only the employee's managers can decide on their leave requests
+	// jane: <nil>
+}
+
+func ExampleImportCSV() {
+	repo := employee.NewMemoryRepository()
+
+	// HR's spreadsheets are imported as CSV. Headers are matched to the columns ignoring case and spaces, or mapped by hand,
+	// and every invalid row is reported with its line before anybody is added.
+	sheet := "ID,First Name,Surname,Total Leaves,Leaves Taken\nalan,Alan,Turing,25,5\nx,,Hopper,25,2\nedsger,Edsger,Dijkstra,20,21\n"
+	opts := employee.CSVOptions{Headers: map[string]string{"Surname": "lastName"}}
+	_, err := employee.ImportCSV(repo, strings.NewReader(sheet), opts)
+	var invalidRows *employee.CSVError
+	if errors.As(err, &invalidRows) {
+		for _, row := range invalidRows.Rows {
+			fmt.Println(row)
+		}
+	}
+	sheet = "ID,First Name,Surname,Total Leaves,Leaves Taken\nalan,Alan,Turing,25,5\nedsger,Edsger,Dijkstra,20,2.5\n"
+	imported, _ := employee.ImportCSV(repo, strings.NewReader(sheet), opts)
+	employee.WriteCSV(os.Stdout, imported)
+	// Output:
+	// line 3: invalid employee: firstName "": must not be empty
+	// line 4: invalid employee: leavesTaken 21: must not be more than totalLeaves 20
+	// id,firstName,lastName,totalLeaves,leavesTaken,startDate,region,sick.total,sick.taken,parental.total,parental.taken,unpaid.total,unpaid.taken,compensatory.total,compensatory.taken,pay.basis,pay.rate
+	// alan,Alan,Turing,25,5,,,0,0,0,0,0,0,0,0,,
+	// edsger,Edsger,Dijkstra,20,2.5,,,0,0,0,0,0,0,0,0,,
+}
+
+func ExampleObserve() {
+	// New returns an employee.Employee, which other packages can name and keep in their own structs.
+	// It never changes: Rename and TakeLeave check the change and return a changed copy, which is saved with Update.
+	// A repository wrapped by Observe reports every change it saves as an event.
+	type team struct {
+		name string
+		lead employee.Employee
+	}
+	observed := employee.Observe(employee.NewMemoryRepository(), func(ev employee.Event) {
+		fmt.Println("event:", ev)
+	})
+	barbara, _ := employee.New("Barbara", "Liskov", 25, 0, employee.WithID("barbara"))
+	compilers := team{name: "Compilers"}
+	compilers.lead, _ = observed.Create(barbara)
+	renamed, _ := compilers.lead.Rename("Barbara", "Liskov-Huberman")
+	_, err := renamed.Rename("", "Liskov")
+	fmt.Println(err)
+	rested, _ := renamed.TakeLeave(employee.Annual, employee.Days(3))
+	_, err = rested.TakeLeave(employee.Annual, employee.Days(30))
+	fmt.Println(err)
+	if compilers.lead, err = observed.Update(rested); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s is led by %s %s, version %d\n", compilers.name, compilers.lead.FirstName(), compilers.lead.LastName(), compilers.lead.Version())
+	// Output:
+	// event: barbara created version 1: Barbara Liskov
+	// invalid employee: firstName "": must not be empty
+	// employee barbara asked for 30 days of annual leave but has 22 remaining
+	// event: barbara renamed version 2: Barbara Liskov to Barbara Liskov-Huberman
+	// event: barbara leaveTaken version 2: 3 days of annual leave
+	// Compilers is led by Barbara Liskov-Huberman, version 2
+}
@@ -0,0 +1,139 @@
+package employee
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileRepository keeps employees in a JSON file.
+// Every call reads the file, and every change writes the whole file again.
+// That is slow for thousands of employees, but simple, and the file is always complete and readable.
+type fileRepository struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileRepository returns a Repository that stores employees in the JSON file at path.
+// The file is created by the first change if it doesn't exist.
+// It is safe to use from several Goroutines, but not from several programs at once.
+func NewFileRepository(path string) Repository {
+	return &fileRepository{path: path}
+}
+
+func (r *fileRepository) load() (map[string]employee, error) {
+	employees := make(map[string]employee)
+	b, err := os.ReadFile(r.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return employees, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, err
+	}
+	for _, rec := range records {
+		e, err := rec.Employee()
+		if err != nil {
+			return nil, err
+		}
+		employees[rec.ID] = e.value()
+	}
+	return employees, nil
+}
+
+// save writes the employees to a temporary file and renames it over the old one,
+// so a crash half way through never leaves a half written file behind
+func (r *fileRepository) save(employees map[string]employee) error {
+	l := list(employees)
+	records := make([]Record, len(l))
+	for i, e := range l {
+		records[i] = e.Record()
+	}
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
+
+// change loads the file, applies fn and saves the result if fn succeeds
+func (r *fileRepository) change(fn func(employees map[string]employee) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	employees, err := r.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(employees); err != nil {
+		return err
+	}
+	return r.save(employees)
+}
+
+func (r *fileRepository) Create(e Employee) (Employee, error) {
+	var created employee
+	err := r.change(func(employees map[string]employee) error {
+		var err error
+		created, err = create(employees, e.value())
+		return err
+	})
+	return stored(created, err)
+}
+
+func (r *fileRepository) Get(id string) (Employee, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	employees, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	e, ok := employees[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+func (r *fileRepository) Update(e Employee) (Employee, error) {
+	var updated employee
+	err := r.change(func(employees map[string]employee) error {
+		var err error
+		updated, err = update(employees, e.value())
+		return err
+	})
+	return stored(updated, err)
+}
+
+func (r *fileRepository) Delete(id string, version int) error {
+	return r.change(func(employees map[string]employee) error {
+		return remove(employees, id, version)
+	})
+}
+
+func (r *fileRepository) List() ([]Employee, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	employees, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	return list(employees), nil
+}
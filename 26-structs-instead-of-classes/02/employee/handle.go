@@ -0,0 +1,211 @@
+package employee
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Employee is what other packages see of an employee. New, Repository and Leaves all return one,
+// so it can be named, passed around and kept in other structs, which the unexported employee can't.
+//
+// An Employee is a value that never changes. Methods such as Rename and TakeLeave check the change,
+// return an error if it would make the employee invalid, and otherwise return a new Employee with the change made,
+// which is saved by passing it to Repository.Update.
+// Every change is recorded as an Event, see Changes and Observe.
+//
+// The interface has an unexported method, so only this package can implement it
+// and every Employee has been through New's validation.
+type Employee interface {
+	ID() string
+	Version() int
+	FirstName() string
+	LastName() string
+	StartDate() time.Time
+	Region() string
+	Pay() Pay
+	Allowance(t LeaveType) Allowance
+	Balance() Balance
+	Record() Record
+	LeavesRemaining()
+
+	// Rename returns the employee with a new first and last name.
+	Rename(firstName, lastName string) (Employee, error)
+	// TakeLeave returns the employee with amount more leave of type t taken today,
+	// or an *InsufficientLeaveError if they don't have that much left.
+	// Leave taken on other days, such as the days of a request, is taken with Leaves.
+	TakeLeave(t LeaveType, amount Amount) (Employee, error)
+	// GrantLeave returns the employee with amount more leave of type t to take.
+	GrantLeave(t LeaveType, amount Amount) (Employee, error)
+	// Changes returns the changes made since the employee was read from a Repository, oldest first.
+	Changes() []Event
+
+	// value returns the employee itself, for the code of this package
+	value() employee
+}
+
+// FirstName returns the employee's first name.
+func (e employee) FirstName() string {
+	return e.firstName
+}
+
+// LastName returns the employee's last name.
+func (e employee) LastName() string {
+	return e.lastName
+}
+
+func (e employee) Rename(firstName, lastName string) (Employee, error) {
+	if firstName == e.firstName && lastName == e.lastName {
+		return e, nil
+	}
+	changed := e
+	changed.firstName, changed.lastName = firstName, lastName
+	return changed.changed(Renamed, fmt.Sprintf("%s %s to %s %s", e.firstName, e.lastName, firstName, lastName))
+}
+
+func (e employee) TakeLeave(t LeaveType, amount Amount) (Employee, error) {
+	return e.takeLeave(t, amount, []LeaveDay{{Type: t, Day: day(time.Now()), Amount: amount}})
+}
+
+// takeLeave takes amount of leave of type t, which was taken on days
+func (e employee) takeLeave(t LeaveType, amount Amount, days []LeaveDay) (Employee, error) {
+	if err := checkType(t); err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("leave to take must be more than 0, not %s", amount)
+	}
+	if err := checkUnit(t, amount); err != nil {
+		return nil, err
+	}
+	if err := checkBalance(e, t, amount); err != nil {
+		return nil, err
+	}
+	changed := e
+	changed.allowance(t).Taken += amount
+	// like changes, the three index slice makes append copy
+	changed.leaveDays = append(e.leaveDays[:len(e.leaveDays):len(e.leaveDays)], days...)
+	return changed.changed(LeaveTaken, fmt.Sprintf("%s days of %s leave", amount, t))
+}
+
+func (e employee) GrantLeave(t LeaveType, amount Amount) (Employee, error) {
+	if err := checkType(t); err != nil {
+		return nil, err
+	}
+	if RulesFor(t).Unlimited {
+		return nil, fmt.Errorf("%s leave is unlimited, it can't be granted", t)
+	}
+	if amount == 0 {
+		return e, nil
+	}
+	changed := e
+	changed.allowance(t).Total += amount
+	return changed.changed(LeaveGranted, fmt.Sprintf("%s days of %s leave", amount, t))
+}
+
+func (e employee) Changes() []Event {
+	return append([]Event(nil), e.changes...)
+}
+
+func (e employee) value() employee {
+	return e
+}
+
+// changed validates e, which has just been changed, and records the change
+func (e employee) changed(kind EventKind, detail string) (Employee, error) {
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+	// the three index slice makes append copy, so employees changed from the same one don't share their changes
+	e.changes = append(e.changes[:len(e.changes):len(e.changes)], Event{Kind: kind, EmployeeID: e.id, Detail: detail})
+	return e, nil
+}
+
+// EventKind is the kind of change an Event describes.
+type EventKind string
+
+const (
+	Created EventKind = "created"
+	// Updated is a change which was not made through a method of Employee, such as replacing the whole employee
+	Updated      EventKind = "updated"
+	Deleted      EventKind = "deleted"
+	Renamed      EventKind = "renamed"
+	LeaveTaken   EventKind = "leaveTaken"
+	LeaveGranted EventKind = "leaveGranted"
+	LeaveAccrued EventKind = "leaveAccrued"
+)
+
+// An Event is one change to an employee.
+type Event struct {
+	Kind       EventKind `json:"kind"`
+	EmployeeID string    `json:"employeeId"`
+	// Version is the version the change was saved with, it is 0 for Deleted
+	Version int `json:"version"`
+	// Detail says what changed, such as "Sam Adolf to Samuel Adolf"
+	Detail string `json:"detail,omitempty"`
+	// Time is when the change was saved
+	Time time.Time `json:"time"`
+}
+
+func (ev Event) String() string {
+	s := fmt.Sprintf("%s %s version %d", ev.EmployeeID, ev.Kind, ev.Version)
+	if ev.Detail != "" {
+		s += ": " + ev.Detail
+	}
+	return s
+}
+
+// observed is a Repository which reports the changes it saves
+type observed struct {
+	Repository
+	notify func(ev Event)
+	now    func() time.Time
+}
+
+// Observe returns a Repository which keeps employees in repo and calls notify with an Event for every change it saves:
+// one for every change made to an Employee since it was read, and a single Created, Updated or Deleted event otherwise.
+// Changes which fail, for example with a *ConflictError, are not reported.
+// notify is called after the change is saved, by the Goroutine which made it.
+func Observe(repo Repository, notify func(ev Event)) Repository {
+	if notify == nil {
+		panic(errors.New("employee: Observe needs a notify function"))
+	}
+	return &observed{Repository: repo, notify: notify, now: time.Now}
+}
+
+func (o *observed) Create(e Employee) (Employee, error) {
+	created, err := o.Repository.Create(e)
+	if err == nil {
+		o.publish(created, []Event{{Kind: Created, Detail: created.FirstName() + " " + created.LastName()}})
+	}
+	return created, err
+}
+
+func (o *observed) Update(e Employee) (Employee, error) {
+	changes := e.Changes()
+	updated, err := o.Repository.Update(e)
+	if err != nil {
+		return updated, err
+	}
+	if len(changes) == 0 {
+		changes = []Event{{Kind: Updated}}
+	}
+	o.publish(updated, changes)
+	return updated, nil
+}
+
+func (o *observed) Delete(id string, version int) error {
+	if err := o.Repository.Delete(id, version); err != nil {
+		return err
+	}
+	o.notify(Event{Kind: Deleted, EmployeeID: id, Time: o.now()})
+	return nil
+}
+
+func (o *observed) publish(e Employee, events []Event) {
+	now := o.now()
+	for _, ev := range events {
+		ev.EmployeeID, ev.Version, ev.Time = e.ID(), e.Version(), now
+		o.notify(ev)
+	}
+}
@@ -0,0 +1,419 @@
+package employee
+
+import (
+	"errors"
+	"fmt"
+	"oop/employee/calendar"
+	"oop/employee/org"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LeaveType is the kind of leave an employee asks for.
+// Every type has its own Allowance, and its own TypeRules, see leavetype.go.
+type LeaveType string
+
+const (
+	Annual   LeaveType = "annual"
+	Sick     LeaveType = "sick"
+	Parental LeaveType = "parental"
+	// Unpaid leave is not taken from any allowance
+	Unpaid       LeaveType = "unpaid"
+	Compensatory LeaveType = "compensatory"
+)
+
+// Status is where a leave request is in the workflow.
+// Every request starts Pending and is then either Approved or Rejected, after which it can't change.
+type Status string
+
+const (
+	Pending  Status = "pending"
+	Approved Status = "approved"
+	Rejected Status = "rejected"
+)
+
+// A LeaveRequest asks for leave from From to To, both days included,
+// or for Part of the day From if it is only part of a day.
+type LeaveRequest struct {
+	ID         string    `json:"id"`
+	EmployeeID string    `json:"employeeId"`
+	Type       LeaveType `json:"type"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	// Part is zero for whole days
+	Part Amount `json:"part,omitempty"`
+	// WorkingDays is the number of days from From to To which are working days, it is set by Submit
+	WorkingDays int `json:"workingDays"`
+	// LeaveDays is the leave taken on each of those days, also set by Submit.
+	// Approving the request takes exactly this, even if the calendar changed in between.
+	LeaveDays []LeaveDay `json:"leaveDays,omitempty"`
+	Note      string     `json:"note,omitempty"`
+	Status    Status     `json:"status"`
+	// DecidedBy is the manager who approved or rejected the request
+	DecidedBy string `json:"decidedBy,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Days returns the number of days the request covers, including weekends and holidays.
+func (r LeaveRequest) Days() int {
+	return int(day(r.To).Sub(day(r.From)).Hours()/24) + 1
+}
+
+// Leave returns the amount of leave the request asks for.
+// Only working days are counted, weekends and public holidays don't need to be taken off.
+func (r LeaveRequest) Leave() Amount {
+	if len(r.LeaveDays) > 0 {
+		var leave Amount
+		for _, d := range r.LeaveDays {
+			leave += d.Amount
+		}
+		return leave
+	}
+	if r.Part > 0 {
+		return r.Part
+	}
+	if r.WorkingDays > 0 {
+		return Days(r.WorkingDays)
+	}
+	return Days(r.Days())
+}
+
+// clone returns a copy of r which doesn't share its LeaveDays, so callers can't change the request Leaves keeps
+func (r *LeaveRequest) clone() LeaveRequest {
+	c := *r
+	c.LeaveDays = slices.Clone(r.LeaveDays)
+	return c
+}
+
+// day drops the time of day from t, so a request from Monday 17:00 to Tuesday 09:00 is two days
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// An AuditEntry records one thing that happened to a leave request.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"requestId"`
+	EmployeeID string    `json:"employeeId"`
+	// Action is the status the request moved to
+	Action Status `json:"action"`
+	// By is the employee for submissions and the manager for decisions
+	By   string `json:"by"`
+	Note string `json:"note,omitempty"`
+}
+
+// A LeaveDay is leave of one type taken on one day.
+// Every way of taking leave records them, from Employee.TakeLeave to approving a request,
+// so unlike the Taken of an Allowance they say when the leave was taken, see Leaves.Taken.
+type LeaveDay struct {
+	Type   LeaveType `json:"type"`
+	Day    time.Time `json:"day"`
+	Amount Amount    `json:"amount"`
+}
+
+var (
+	// ErrRequestNotFound is returned when there is no leave request with the requested id.
+	ErrRequestNotFound = errors.New("leave request not found")
+	// ErrDecided is returned when approving or rejecting a request which is no longer pending.
+	ErrDecided = errors.New("leave request has already been decided")
+	// ErrSelfApproval is returned when a manager tries to decide on their own request.
+	ErrSelfApproval = errors.New("employees can't decide on their own leave requests")
+	// ErrNotManager is returned when someone who doesn't manage the employee tries to decide on their request, see UseChart.
+	ErrNotManager = errors.New("only the employee's managers can decide on their leave requests")
+)
+
+// An InsufficientLeaveError is returned when a request asks for more days than the employee has left.
+type InsufficientLeaveError struct {
+	EmployeeID string
+	Type       LeaveType
+	Requested  Amount
+	Remaining  Amount
+}
+
+func (i *InsufficientLeaveError) Error() string {
+	return fmt.Sprintf("employee %s asked for %s days of %s leave but has %s remaining", i.EmployeeID, i.Requested, i.Type, i.Remaining)
+}
+
+// Leaves runs the leave workflow for the employees in a Repository:
+// employees Submit requests, managers Approve or Reject them,
+// and approved leave is added to the employee's leaves taken.
+// It is safe to use from several Goroutines.
+type Leaves struct {
+	repo Repository
+	now  func() time.Time
+	// cal is nil if every day is a working day
+	cal *calendar.Calendar
+	// chart is nil if anybody can decide on anybody's requests
+	chart *org.Chart
+
+	mu       sync.Mutex
+	nextID   int
+	requests map[string]*LeaveRequest
+	// order is the request ids in the order they were submitted
+	order []string
+	audit []AuditEntry
+}
+
+// NewLeaves returns the leave workflow for the employees in repo.
+// Until UseCalendar is called every day counts as a working day.
+func NewLeaves(repo Repository) *Leaves {
+	return &Leaves{repo: repo, now: time.Now, requests: make(map[string]*LeaveRequest)}
+}
+
+// UseCalendar makes leave requests count only the working days in cal,
+// in the region of the employee who asks for the leave, see WithRegion.
+// It affects the requests submitted after it is called.
+func (l *Leaves) UseCalendar(cal *calendar.Calendar) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cal = cal
+}
+
+// UseChart makes sure that only the employee's manager, or their manager's manager and so on, can decide on their requests.
+// The members of chart are identified by employee id.
+func (l *Leaves) UseChart(chart *org.Chart) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.chart = chart
+}
+
+// WorkingDays returns the number of working days from from to to, both included, in region,
+// with the calendar given to UseCalendar.
+func (l *Leaves) WorkingDays(region string, from, to time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.workingDays(region, from, to)
+}
+
+// workingDays returns the working days from from to to in region, the caller must hold mu
+func (l *Leaves) workingDays(region string, from, to time.Time) int {
+	if l.cal == nil {
+		return int(day(to).Sub(day(from)).Hours()/24) + 1
+	}
+	return l.cal.WorkingDays(region, from, to)
+}
+
+// Submit files a pending leave request for the employee with the given id.
+// It fails if the dates are the wrong way round, or if the employee doesn't have enough leave left
+// for this request and the ones still pending.
+func (l *Leaves) Submit(employeeID string, typ LeaveType, from, to time.Time, note string) (LeaveRequest, error) {
+	return l.submit(LeaveRequest{EmployeeID: employeeID, Type: typ, From: from, To: to, Note: note})
+}
+
+// SubmitPart files a pending leave request for part of a day, such as an afternoon or a couple of hours.
+func (l *Leaves) SubmitPart(employeeID string, typ LeaveType, on time.Time, part Amount, note string) (LeaveRequest, error) {
+	if part <= 0 || part >= Day {
+		return LeaveRequest{}, fmt.Errorf("part of a day must be more than 0 and less than a day, not %s", part)
+	}
+	return l.submit(LeaveRequest{EmployeeID: employeeID, Type: typ, From: on, To: on, Part: part, Note: note})
+}
+
+func (l *Leaves) submit(r LeaveRequest) (LeaveRequest, error) {
+	if err := checkType(r.Type); err != nil {
+		return LeaveRequest{}, err
+	}
+	if err := checkUnit(r.Type, r.Leave()); err != nil {
+		return LeaveRequest{}, err
+	}
+	if day(r.To).Before(day(r.From)) {
+		return LeaveRequest{}, fmt.Errorf("leave ends on %s, before it starts on %s", r.To.Format(time.DateOnly), r.From.Format(time.DateOnly))
+	}
+	e, err := l.repo.Get(r.EmployeeID)
+	if err != nil {
+		return LeaveRequest{}, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r.Status = Pending
+	r.WorkingDays = l.workingDays(e.Region(), r.From, r.To)
+	r.LeaveDays = l.requestDays(r, e.Region())
+	if r.WorkingDays == 0 {
+		return LeaveRequest{}, fmt.Errorf("there are no working days from %s to %s", r.From.Format(time.DateOnly), r.To.Format(time.DateOnly))
+	}
+	// leave which is already asked for counts against the balance too, otherwise two requests could each fit but not both
+	if err := checkBalance(e.value(), r.Type, r.Leave()+l.pendingLeave(r.EmployeeID, r.Type)); err != nil {
+		return LeaveRequest{}, err
+	}
+	l.nextID++
+	r.ID = strconv.Itoa(l.nextID)
+	l.requests[r.ID] = &r
+	l.order = append(l.order, r.ID)
+	l.record(r, r.EmployeeID, r.Note)
+	return r.clone(), nil
+}
+
+// Approve approves a pending request and adds its days to the leave of its type the employee has taken.
+// The balance is checked again, since it may have changed since the request was submitted.
+func (l *Leaves) Approve(requestID string, manager string) (LeaveRequest, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r, err := l.pending(requestID, manager)
+	if err != nil {
+		return LeaveRequest{}, err
+	}
+	if _, err := l.take(r.EmployeeID, r.Type, r.LeaveDays, 0); err != nil {
+		return LeaveRequest{}, err
+	}
+	r.Status = Approved
+	r.DecidedBy = manager
+	l.record(*r, manager, "")
+	return r.clone(), nil
+}
+
+// Reject rejects a pending request, giving the reason why.
+func (l *Leaves) Reject(requestID string, manager string, reason string) (LeaveRequest, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r, err := l.pending(requestID, manager)
+	if err != nil {
+		return LeaveRequest{}, err
+	}
+	r.Status = Rejected
+	r.DecidedBy = manager
+	r.Reason = reason
+	l.record(*r, manager, reason)
+	return r.clone(), nil
+}
+
+// Request returns the leave request with the given id.
+func (l *Leaves) Request(id string) (LeaveRequest, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r, ok := l.requests[id]
+	if !ok {
+		return LeaveRequest{}, ErrRequestNotFound
+	}
+	return r.clone(), nil
+}
+
+// Requests returns the leave requests of an employee in the order they were submitted.
+func (l *Leaves) Requests(employeeID string) []LeaveRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var rs []LeaveRequest
+	for _, id := range l.order {
+		if r := l.requests[id]; r.EmployeeID == employeeID {
+			rs = append(rs, r.clone())
+		}
+	}
+	return rs
+}
+
+// History returns every submission and decision, oldest first.
+func (l *Leaves) History() []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]AuditEntry(nil), l.audit...)
+}
+
+// pending returns the request if it can still be decided on by manager
+func (l *Leaves) pending(requestID string, manager string) (*LeaveRequest, error) {
+	r, ok := l.requests[requestID]
+	if !ok {
+		return nil, ErrRequestNotFound
+	}
+	if r.Status != Pending {
+		return nil, ErrDecided
+	}
+	if manager == r.EmployeeID {
+		return nil, ErrSelfApproval
+	}
+	if l.chart != nil && !l.chart.Manages(manager, r.EmployeeID) {
+		return nil, ErrNotManager
+	}
+	return r, nil
+}
+
+// pendingLeave returns the leave of type t the employee has asked for which is not decided yet
+func (l *Leaves) pendingLeave(employeeID string, t LeaveType) Amount {
+	var pending Amount
+	for _, r := range l.requests {
+		if r.EmployeeID == employeeID && r.Status == Pending && r.Type == t {
+			pending += r.Leave()
+		}
+	}
+	return pending
+}
+
+// checkUnit returns an error if leave of type t can't be taken in steps as small as leave
+func checkUnit(t LeaveType, leave Amount) error {
+	if unit := RulesFor(t).Unit; leave%unit != 0 {
+		if unit == Day {
+			return fmt.Errorf("%s leave must be taken in whole days", t)
+		}
+		return fmt.Errorf("%s leave must be taken in multiples of %s days", t, unit)
+	}
+	return nil
+}
+
+func checkBalance(e employee, t LeaveType, leave Amount) error {
+	if RulesFor(t).Unlimited {
+		return nil
+	}
+	if remaining := e.Allowance(t).Remaining(); leave > remaining {
+		return &InsufficientLeaveError{EmployeeID: e.id, Type: t, Requested: leave, Remaining: remaining}
+	}
+	return nil
+}
+
+// Take adds leave to the leave of type t the employee has taken, without a request.
+// It is for leave which was agreed on outside the workflow, such as leave entered from the command line,
+// and like Employee.TakeLeave it still refuses to take more than the employee has left.
+func (l *Leaves) Take(employeeID string, t LeaveType, leave Amount) (Employee, error) {
+	// requests which are still pending have a claim on the balance too
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// it is taken today, like Employee.TakeLeave but by the workflow's clock
+	return l.take(employeeID, t, []LeaveDay{{Type: t, Day: day(l.now()), Amount: leave}}, l.pendingLeave(employeeID, t))
+}
+
+// take adds the leave taken on days to the leave of type t the employee has taken, if there is enough left besides reserved.
+// Someone else may update the employee between our Get and Update, in which case we read it again and retry.
+func (l *Leaves) take(employeeID string, t LeaveType, days []LeaveDay, reserved Amount) (Employee, error) {
+	var leave Amount
+	for _, d := range days {
+		leave += d.Amount
+	}
+	for {
+		e, err := l.repo.Get(employeeID)
+		if err != nil {
+			return nil, err
+		}
+		if reserved > 0 {
+			if err := checkBalance(e.value(), t, leave+reserved); err != nil {
+				return nil, err
+			}
+		}
+		taken, err := e.value().takeLeave(t, leave, days)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := l.repo.Update(taken)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) {
+			return updated, err
+		}
+	}
+}
+
+// requestDays spreads the leave a request asks for over its working days, the caller holds mu
+func (l *Leaves) requestDays(r LeaveRequest, region string) []LeaveDay {
+	if r.Part > 0 {
+		return []LeaveDay{{Type: r.Type, Day: day(r.From), Amount: r.Part}}
+	}
+	var days []LeaveDay
+	for d := day(r.From); !d.After(day(r.To)); d = d.AddDate(0, 0, 1) {
+		if l.cal == nil || l.cal.IsWorkingDay(region, d) {
+			days = append(days, LeaveDay{Type: r.Type, Day: d, Amount: Day})
+		}
+	}
+	return days
+}
+
+func (l *Leaves) record(r LeaveRequest, by string, note string) {
+	l.audit = append(l.audit, AuditEntry{Time: l.now(), RequestID: r.ID, EmployeeID: r.EmployeeID, Action: r.Status, By: by, Note: note})
+}
@@ -0,0 +1,59 @@
+package employee
+
+import (
+	"oop/employee/calendar"
+	"testing"
+	"time"
+)
+
+func TestApproveTakesTheDaysSubmitted(t *testing.T) {
+	repo := NewMemoryRepository()
+	leaves := NewLeaves(repo)
+	for _, id := range []string{"ada", "grace"} {
+		e, err := New("Ada", "Lovelace", 20, 0, WithID(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Create(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Thursday the 4th of July is a holiday, so the week off is 4 days of leave
+	cal := calendar.New()
+	cal.Add("", date("2024-07-04"), "Independence Day")
+	leaves.UseCalendar(cal)
+	r, err := leaves.Submit("ada", Annual, date("2024-07-01"), date("2024-07-05"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Leave() != Days(4) || len(r.LeaveDays) != 4 {
+		t.Fatalf("submitted %s days on %v, want 4", r.Leave(), r.LeaveDays)
+	}
+	// changing the copy we got back doesn't change the request
+	r.LeaveDays[0].Amount = Days(10)
+
+	// the holiday is taken off the calendar before the request is approved
+	leaves.UseCalendar(calendar.New())
+	r, err = leaves.Approve(r.ID, "grace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Leave() != Days(4) {
+		t.Errorf("approved %s days, want the 4 submitted", r.Leave())
+	}
+	e, err := repo.Get("ada")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taken := e.Allowance(Annual).Taken; taken != Days(4) {
+		t.Errorf("%s days taken, want 4", taken)
+	}
+	taken := leaves.Taken("ada", Annual)
+	if got := taken(date("2024-07-04"), date("2024-07-05")); got != 0 {
+		t.Errorf("%s days taken on the holiday, want 0", got)
+	}
+	if got := taken(date("2024-07-01"), time.Date(2024, 7, 6, 0, 0, 0, 0, time.UTC)); got != Days(4) {
+		t.Errorf("%s days taken in the week, want 4", got)
+	}
+}
@@ -0,0 +1,117 @@
+package employee
+
+import (
+	"errors"
+	"fmt"
+)
+
+// numLeaveTypes is the number of leave types, an employee has an Allowance for each of them
+const numLeaveTypes = 5
+
+// leaveTypes lists every LeaveType, its position is where the employee's Allowance of that type is kept
+var leaveTypes = [numLeaveTypes]LeaveType{Annual, Sick, Parental, Unpaid, Compensatory}
+
+// LeaveTypes returns every leave type, annual leave first.
+func LeaveTypes() []LeaveType {
+	return leaveTypes[:]
+}
+
+// index returns the position of t in leaveTypes
+func (t LeaveType) index() (int, bool) {
+	for i, lt := range leaveTypes {
+		if lt == t {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// TypeRules are the rules which differ between leave types.
+type TypeRules struct {
+	// Unlimited leave has no allowance, it can always be taken and is not counted in the summary of an employee's leave
+	Unlimited bool
+	// Unit is the smallest part of this leave that can be asked for, requests must be a multiple of it
+	Unit Amount
+}
+
+var typeRules = map[LeaveType]TypeRules{
+	Annual: {Unit: QuarterHour},
+	Sick:   {Unit: QuarterHour},
+	// parental leave is taken in whole days
+	Parental: {Unit: Day},
+	Unpaid:   {Unlimited: true, Unit: QuarterHour},
+	// compensatory leave is earned by working overtime, see Leaves.Grant
+	Compensatory: {Unit: QuarterHour},
+}
+
+// RulesFor returns the rules of leave type t.
+func RulesFor(t LeaveType) TypeRules {
+	return typeRules[t]
+}
+
+// An Allowance is how much leave of one type an employee has, and how much of it they have taken.
+type Allowance struct {
+	Total Amount `json:"total"`
+	Taken Amount `json:"taken"`
+}
+
+// Remaining returns what is left of the allowance.
+func (a Allowance) Remaining() Amount {
+	return a.Total - a.Taken
+}
+
+// WithAllowance sets the employee's leave of type t.
+// If t is not a leave type, New returns a *ValidationError for it.
+func WithAllowance(t LeaveType, total, taken Amount) Option {
+	return func(e *employee) {
+		i, ok := t.index()
+		if !ok {
+			e.problems = append(e.problems, FieldError{Field: "leave", Value: t, Problem: "is not a leave type"})
+			return
+		}
+		e.leave[i] = Allowance{Total: total, Taken: taken}
+	}
+}
+
+// Allowance returns the employee's leave of type t.
+func (e employee) Allowance(t LeaveType) Allowance {
+	i, ok := t.index()
+	if !ok {
+		return Allowance{}
+	}
+	return e.leave[i]
+}
+
+// allowance returns a pointer to the employee's leave of type t, so it can be changed
+func (e *employee) allowance(t LeaveType) *Allowance {
+	i, _ := t.index()
+	return &e.leave[i]
+}
+
+// checkType returns an error if t is not a known leave type
+func checkType(t LeaveType) error {
+	if _, ok := t.index(); !ok {
+		return fmt.Errorf("unknown leave type %q", t)
+	}
+	return nil
+}
+
+// Grant adds amount to the employee's allowance of leave type t,
+// for example compensatory leave for a weekend worked.
+func (l *Leaves) Grant(employeeID string, t LeaveType, amount Amount) (Employee, error) {
+	for {
+		e, err := l.repo.Get(employeeID)
+		if err != nil {
+			return nil, err
+		}
+		granted, err := e.GrantLeave(t, amount)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := l.repo.Update(granted)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) {
+			return updated, err
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package employee
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithAllowance(t *testing.T) {
+	e, err := New("Grace", "Hopper", 25, 0, WithAllowance(Sick, Days(10), Days(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := e.Allowance(Sick); a != (Allowance{Total: Days(10), Taken: Days(2)}) {
+		t.Errorf("sick leave is %+v, want 10 days with 2 taken", a)
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		// fields are the invalid fields New reports, in order
+		fields []string
+	}{
+		{"unknown type", []Option{WithAllowance("holiday", Days(5), 0)}, []string{"leave"}},
+		{"unknown type and a bad allowance", []Option{WithAllowance("holiday", Days(5), 0), WithAllowance(Sick, -Day, 0)}, []string{"leave", "sick.total"}},
+		{"more taken than there is", []Option{WithAllowance(Sick, Day, Days(2))}, []string{"sick.taken"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New("Grace", "Hopper", 25, 0, tt.opts...)
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("New returned %v, want a *ValidationError", err)
+			}
+			if len(invalid.Fields) != len(tt.fields) {
+				t.Fatalf("invalid fields %v, want %v", invalid.Fields, tt.fields)
+			}
+			for i, f := range tt.fields {
+				if invalid.Fields[i].Field != f {
+					t.Errorf("invalid field %d is %v, want %s", i, invalid.Fields[i], f)
+				}
+			}
+		})
+	}
+
+	_, err = New("Grace", "Hopper", 25, 0, WithAllowance("holiday", Days(5), 0))
+	if want := `invalid employee: leave holiday: is not a leave type`; err == nil || err.Error() != want {
+		t.Errorf("error %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,333 @@
+// Package org models who works for whom: every member of an organisation has a manager,
+// except the ones at the top, and belongs to a team in a department.
+// Members are identified by their employee id, so a Chart can sit next to an employee.Repository.
+package org
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Member is one person in the org chart.
+type Member struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// ManagerID is empty for the people at the top of the organisation
+	ManagerID  string `json:"managerId,omitempty"`
+	Department string `json:"department,omitempty"`
+	Team       string `json:"team,omitempty"`
+}
+
+var (
+	// ErrNotFound is returned when there is no member with the requested id.
+	ErrNotFound = errors.New("member not found")
+	// ErrExists is returned by Add when a member with the same id is already in the chart.
+	ErrExists = errors.New("member already exists")
+)
+
+// A CycleError is returned when a change would make someone their own manager, directly or through others.
+type CycleError struct {
+	// Cycle is the ids of the members in the cycle, starting and ending with the same one
+	Cycle []string
+}
+
+func (c *CycleError) Error() string {
+	return "management cycle: " + strings.Join(c.Cycle, " -> ")
+}
+
+// A Chart is an org chart. It is safe to use from several Goroutines.
+type Chart struct {
+	mu      sync.RWMutex
+	members map[string]Member
+}
+
+// New returns an empty org chart.
+func New() *Chart {
+	return &Chart{members: make(map[string]Member)}
+}
+
+// Add adds m to the chart. Its manager must already be in it.
+func (c *Chart) Add(m Member) error {
+	if m.ID == "" {
+		return errors.New("member has no id")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.members[m.ID]; ok {
+		return ErrExists
+	}
+	if m.ManagerID != "" {
+		if _, ok := c.members[m.ManagerID]; !ok {
+			return fmt.Errorf("manager %s: %w", m.ManagerID, ErrNotFound)
+		}
+	}
+	if m.ManagerID == m.ID {
+		return &CycleError{Cycle: []string{m.ID, m.ID}}
+	}
+	c.members[m.ID] = m
+	return nil
+}
+
+// Get returns the member with the given id.
+func (c *Chart) Get(id string) (Member, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	m, ok := c.members[id]
+	if !ok {
+		return Member{}, ErrNotFound
+	}
+	return m, nil
+}
+
+// Members returns everyone in the chart, sorted by department, team, name and id.
+func (c *Chart) Members() []Member {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ms := make([]Member, 0, len(c.members))
+	for _, m := range c.members {
+		ms = append(ms, m)
+	}
+	sortMembers(ms)
+	return ms
+}
+
+func sortMembers(ms []Member) {
+	sort.Slice(ms, func(i, j int) bool {
+		a, b := ms[i], ms[j]
+		if a.Department != b.Department {
+			return a.Department < b.Department
+		}
+		if a.Team != b.Team {
+			return a.Team < b.Team
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+}
+
+// SetManager makes managerID the manager of id, or takes id's manager away if managerID is empty.
+// It returns a *CycleError, and changes nothing, if id would end up managing itself.
+func (c *Chart) SetManager(id, managerID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.members[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if managerID != "" {
+		if _, ok := c.members[managerID]; !ok {
+			return fmt.Errorf("manager %s: %w", managerID, ErrNotFound)
+		}
+		// walk up from the new manager, if we meet id it would be managing itself
+		cycle := []string{id, managerID}
+		for up := managerID; up != ""; up = c.members[up].ManagerID {
+			if up == id {
+				return &CycleError{Cycle: cycle}
+			}
+			if next := c.members[up].ManagerID; next != "" {
+				cycle = append(cycle, next)
+			}
+		}
+	}
+	m.ManagerID = managerID
+	c.members[id] = m
+	return nil
+}
+
+// Move puts the member in another department and team.
+func (c *Chart) Move(id, department, team string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.members[id]
+	if !ok {
+		return ErrNotFound
+	}
+	m.Department, m.Team = department, team
+	c.members[id] = m
+	return nil
+}
+
+// Remove takes the member out of the chart. Their direct reports now report to the member's manager.
+func (c *Chart) Remove(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.members[id]
+	if !ok {
+		return ErrNotFound
+	}
+	for rid, r := range c.members {
+		if r.ManagerID == id {
+			r.ManagerID = m.ManagerID
+			c.members[rid] = r
+		}
+	}
+	delete(c.members, id)
+	return nil
+}
+
+// DirectReports returns the members whose manager is id.
+func (c *Chart) DirectReports(id string) []Member {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var rs []Member
+	for _, m := range c.members {
+		if m.ManagerID == id && id != "" {
+			rs = append(rs, m)
+		}
+	}
+	sortMembers(rs)
+	return rs
+}
+
+// Reports returns everyone who reports to id, directly or through other managers.
+func (c *Chart) Reports(id string) []Member {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	// reports maps a manager to their direct reports, so the chart is walked once per level instead of once per member
+	reports := make(map[string][]string)
+	for _, m := range c.members {
+		if m.ManagerID != "" {
+			reports[m.ManagerID] = append(reports[m.ManagerID], m.ID)
+		}
+	}
+	var rs []Member
+	queue := reports[id]
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		rs = append(rs, c.members[r])
+		queue = append(queue, reports[r]...)
+	}
+	sortMembers(rs)
+	return rs
+}
+
+// Chain returns id's manager, their manager and so on up to the top of the organisation.
+func (c *Chart) Chain(id string) ([]Member, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	m, ok := c.members[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var chain []Member
+	for up := m.ManagerID; up != ""; up = c.members[up].ManagerID {
+		chain = append(chain, c.members[up])
+	}
+	return chain, nil
+}
+
+// Manages reports whether managerID is id's manager, or their manager's manager and so on.
+func (c *Chart) Manages(managerID, id string) bool {
+	chain, err := c.Chain(id)
+	if err != nil {
+		return false
+	}
+	for _, m := range chain {
+		if m.ID == managerID {
+			return true
+		}
+	}
+	return false
+}
+
+// Departments returns the names of the departments, sorted.
+func (c *Chart) Departments() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.names(func(m Member) (string, bool) { return m.Department, m.Department != "" })
+}
+
+// Teams returns the names of the teams in department, sorted.
+func (c *Chart) Teams(department string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.names(func(m Member) (string, bool) { return m.Team, m.Team != "" && m.Department == department })
+}
+
+// names returns the distinct names which name picks out of the members, sorted
+func (c *Chart) names(name func(m Member) (string, bool)) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range c.members {
+		if n, ok := name(m); ok && !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InDepartment returns the members of department, and only of team if team is not empty.
+func (c *Chart) InDepartment(department, team string) []Member {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var ms []Member
+	for _, m := range c.members {
+		if m.Department == department && (team == "" || m.Team == team) {
+			ms = append(ms, m)
+		}
+	}
+	sortMembers(ms)
+	return ms
+}
+
+// build returns a chart of members, adding them managers first so they can be listed in any order
+func build(members []Member) (*Chart, error) {
+	c := New()
+	byID := make(map[string]Member, len(members))
+	for _, m := range members {
+		if m.ID == "" {
+			return nil, errors.New("member has no id")
+		}
+		if _, ok := byID[m.ID]; ok {
+			return nil, fmt.Errorf("member %s: %w", m.ID, ErrExists)
+		}
+		byID[m.ID] = m
+	}
+	// add, on its way, adds a member's managers before the member and finds cycles
+	const (
+		adding = 1
+		added  = 2
+	)
+	state := make(map[string]int)
+	var add func(id string, path []string) error
+	add = func(id string, path []string) error {
+		switch state[id] {
+		case added:
+			return nil
+		case adding:
+			// path ends at id's report, the cycle is the part of path from id on
+			for i, p := range path {
+				if p == id {
+					return &CycleError{Cycle: append(append([]string(nil), path[i:]...), id)}
+				}
+			}
+		}
+		m := byID[id]
+		state[id] = adding
+		if m.ManagerID != "" {
+			if _, ok := byID[m.ManagerID]; !ok {
+				return fmt.Errorf("member %s: manager %s: %w", id, m.ManagerID, ErrNotFound)
+			}
+			if err := add(m.ManagerID, append(path, id)); err != nil {
+				return err
+			}
+		}
+		state[id] = added
+		c.members[id] = m
+		return nil
+	}
+	for _, m := range members {
+		if err := add(m.ID, nil); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
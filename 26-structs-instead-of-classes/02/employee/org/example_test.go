@@ -0,0 +1,50 @@
+package org_test
+
+import (
+	"fmt"
+	"oop/employee/org"
+	"os"
+)
+
+func ExampleChart() {
+	// An org chart says who manages whom. Reports finds everybody below a manager, however many levels down,
+	// and a change which would make someone their own manager is refused.
+	chart := org.New()
+	for _, m := range []org.Member{
+		{ID: "jane", Name: "Jane Doe", Department: "Engineering"},
+		{ID: "grace", Name: "Grace Hopper", ManagerID: "jane", Department: "Engineering", Team: "Compilers"},
+		{ID: "sam", Name: "Sam Adolf", ManagerID: "grace", Department: "Engineering", Team: "Compilers"},
+		{ID: "ada", Name: "Ada Lovelace", ManagerID: "jane", Department: "Research"},
+	} {
+		if err := chart.Add(m); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	fmt.Print("reports of jane:")
+	for _, m := range chart.Reports("jane") {
+		fmt.Print(" ", m.Name)
+	}
+	fmt.Println()
+	fmt.Println(chart.SetManager("jane", "sam"))
+	chart.WriteDOT(os.Stdout)
+	// Output:
+	// reports of jane: Grace Hopper Sam Adolf Ada Lovelace
+	// management cycle: jane -> sam -> grace -> jane
+	// digraph org {
+	//   node [shape=box];
+	//   subgraph "cluster_Engineering" {
+	//     label="Engineering";
+	//     "jane" [label="Jane Doe", name="Jane Doe", department="Engineering", team=""];
+	//     "grace" [label="Grace Hopper\nCompilers", name="Grace Hopper", department="Engineering", team="Compilers"];
+	//     "sam" [label="Sam Adolf\nCompilers", name="Sam Adolf", department="Engineering", team="Compilers"];
+	//   }
+	//   subgraph "cluster_Research" {
+	//     label="Research";
+	//     "ada" [label="Ada Lovelace", name="Ada Lovelace", department="Research", team=""];
+	//   }
+	//   "jane" -> "grace";
+	//   "grace" -> "sam";
+	//   "jane" -> "ada";
+	// }
+}
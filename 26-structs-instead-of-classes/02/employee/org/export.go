@@ -0,0 +1,187 @@
+package org
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// chartJSON is the JSON form of a chart
+type chartJSON struct {
+	Members []Member `json:"members"`
+}
+
+// WriteJSON writes the chart to w as JSON, the members sorted as Members sorts them.
+func (c *Chart) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(chartJSON{Members: c.Members()})
+}
+
+// ReadJSON reads a chart written by WriteJSON.
+// The members can be in any order, but every manager must be one of them, and nobody can manage themselves.
+func ReadJSON(r io.Reader) (*Chart, error) {
+	var cj chartJSON
+	if err := json.NewDecoder(r).Decode(&cj); err != nil {
+		return nil, err
+	}
+	return build(cj.Members)
+}
+
+// WriteDOT writes the chart to w in the DOT language of Graphviz, so it can be drawn with
+//
+//	dot -Tsvg org.dot > org.svg
+//
+// Every department is drawn as a box around its members, and there is an arrow from every manager to each of their reports.
+// The department and team are also written as attributes of the members, so ReadDOT can read the chart back.
+// Graphviz only knows the escapes \" and \\ in ids, so nothing else in them is escaped.
+func (c *Chart) WriteDOT(w io.Writer) error {
+	members := c.Members()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph org {")
+	fmt.Fprintln(bw, "  node [shape=box];")
+	department, open := "", false
+	for i, m := range members {
+		if m.Department != department || i == 0 {
+			if open {
+				fmt.Fprintln(bw, "  }")
+				open = false
+			}
+			department = m.Department
+			if department != "" {
+				fmt.Fprintf(bw, "  subgraph %s {\n", dotQuote("cluster_"+department))
+				fmt.Fprintf(bw, "    label=%s;\n", dotQuote(department))
+				open = true
+			}
+		}
+		indent := "  "
+		if open {
+			indent = "    "
+		}
+		// \n in a label is a line break to Graphviz, so it goes between the escaped name and team
+		label := dotQuote(m.Name)
+		if m.Team != "" {
+			label = `"` + dotEscaper.Replace(m.Name) + `\n` + dotEscaper.Replace(m.Team) + `"`
+		}
+		fmt.Fprintf(bw, "%s%s [label=%s, name=%s, department=%s, team=%s];\n", indent,
+			dotQuote(m.ID), label, dotQuote(m.Name), dotQuote(m.Department), dotQuote(m.Team))
+	}
+	if open {
+		fmt.Fprintln(bw, "  }")
+	}
+	for _, m := range members {
+		if m.ManagerID != "" {
+			fmt.Fprintf(bw, "  %s -> %s;\n", dotQuote(m.ManagerID), dotQuote(m.ID))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+var (
+	// a node statement, such as "id" [label="Sam", department="Sales"];
+	dotNode = regexp.MustCompile(`^("(?:[^"\\]|\\.)*"|\w+)\s*\[(.*)\]\s*;?$`)
+	// an edge statement, with or without attributes, such as "manager" -> "report" [style=dashed];
+	dotEdge = regexp.MustCompile(`^("(?:[^"\\]|\\.)*"|\w+)\s*->\s*("(?:[^"\\]|\\.)*"|\w+)\s*(?:\[.*\])?\s*;?$`)
+	// one attribute of a node, such as name="Sam"
+	dotAttr = regexp.MustCompile(`(\w+)\s*=\s*("(?:[^"\\]|\\.)*"|\w+)`)
+)
+
+// ReadDOT reads a chart from the DOT written by WriteDOT, or any DOT with one statement per line.
+// Nodes are members: their id is the node id, and their name, department and team come from attributes of the same names.
+// A node without a name attribute is named by its label. An edge from a to b makes a the manager of b.
+// Other statements, such as subgraphs and graph attributes, are skipped.
+func ReadDOT(r io.Reader) (*Chart, error) {
+	var members []Member
+	index := make(map[string]int)
+	member := func(id string) *Member {
+		i, ok := index[id]
+		if !ok {
+			i = len(members)
+			index[id] = i
+			members = append(members, Member{ID: id})
+		}
+		return &members[i]
+	}
+	var edges [][2]string
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if m := dotEdge.FindStringSubmatch(text); m != nil {
+			from, err := unquote(m[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			to, err := unquote(m[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			edges = append(edges, [2]string{from, to})
+			continue
+		}
+		m := dotNode.FindStringSubmatch(text)
+		if m == nil || m[1] == "node" || m[1] == "edge" || m[1] == "graph" {
+			continue
+		}
+		id, err := unquote(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		attrs := make(map[string]string)
+		for _, a := range dotAttr.FindAllStringSubmatch(m[2], -1) {
+			v, err := unquote(a[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			attrs[a[1]] = v
+		}
+		mem := member(id)
+		mem.Name, mem.Department, mem.Team = attrs["name"], attrs["department"], attrs["team"]
+		if _, ok := attrs["name"]; !ok {
+			mem.Name = attrs["label"]
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	for _, e := range edges {
+		member(e[0])
+		report := member(e[1])
+		if report.ManagerID != "" && report.ManagerID != e[0] {
+			return nil, fmt.Errorf("member %s has two managers, %s and %s", e[1], report.ManagerID, e[0])
+		}
+		report.ManagerID = e[0]
+	}
+	return build(members)
+}
+
+// dotEscaper escapes the only two characters which need it in a quoted DOT id
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// dotQuote returns s as a quoted DOT id
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+// unquote returns a DOT id without its quotes.
+// Only \" and \\ are unescaped, any other backslash is part of the id, like the \n line breaks in labels.
+func unquote(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+	if len(s) < 2 || !strings.HasSuffix(s, `"`) {
+		return "", fmt.Errorf("unterminated string %s", s)
+	}
+	var b strings.Builder
+	s = s[1 : len(s)-1]
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,191 @@
+package org
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// members has a bit of everything: several levels, members without a department or team,
+// and names which have to be escaped
+var members = []Member{
+	{ID: "jane", Name: "Jane Doe", Department: "Engineering"},
+	{ID: "grace", Name: `Grace "Amazing Grace" Hopper`, ManagerID: "jane", Department: "Engineering", Team: "Compilers"},
+	{ID: "sam", Name: `Sam \o/ Adolf`, ManagerID: "grace", Department: "Engineering", Team: `C:\Compilers`},
+	{ID: "ada", Name: "Ada Lovelace", ManagerID: "jane", Department: "Research"},
+	{ID: "alan", Name: "Alan Turing"},
+}
+
+func chart(t *testing.T) *Chart {
+	t.Helper()
+	c, err := build(members)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		write func(c *Chart, buf *bytes.Buffer) error
+		read  func(buf *bytes.Buffer) (*Chart, error)
+	}{
+		{"json", func(c *Chart, buf *bytes.Buffer) error { return c.WriteJSON(buf) }, func(buf *bytes.Buffer) (*Chart, error) { return ReadJSON(buf) }},
+		{"dot", func(c *Chart, buf *bytes.Buffer) error { return c.WriteDOT(buf) }, func(buf *bytes.Buffer) (*Chart, error) { return ReadDOT(buf) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			want := chart(t).Members()
+			var buf bytes.Buffer
+			if err := tt.write(chart(t), &buf); err != nil {
+				t.Fatal(err)
+			}
+			written := buf.String()
+			c, err := tt.read(&buf)
+			if err != nil {
+				t.Fatalf("reading back\n%s\nfailed: %v", written, err)
+			}
+			if got := c.Members(); !reflect.DeepEqual(got, want) {
+				t.Errorf("read back\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestWriteDOTEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := chart(t).WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// only quotes and backslashes are escaped, and the \n in the label is Graphviz's line break
+	for _, want := range []string{
+		`"grace" [label="Grace \"Amazing Grace\" Hopper\nCompilers", name="Grace \"Amazing Grace\" Hopper", department="Engineering", team="Compilers"];`,
+		`"sam" [label="Sam \\o/ Adolf\nC:\\Compilers", name="Sam \\o/ Adolf", department="Engineering", team="C:\\Compilers"];`,
+		`"alan" [label="Alan Turing", name="Alan Turing", department="", team=""];`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("the DOT has no line\n%s\nin\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestReadDOT(t *testing.T) {
+	// DOT as someone might write it by hand, with graph attributes, nested subgraphs,
+	// unquoted ids, an edge before its nodes and a node named only by its label
+	dot := `digraph org {
+  rankdir=LR;
+  graph [fontname="Helvetica"];
+  node [shape=box, style=filled];
+  edge [color=grey];
+  subgraph cluster_eng {
+    label="Engineering";
+    color=blue;
+    jane -> grace;
+    jane [name="Jane Doe", department="Engineering", fillcolor=yellow];
+    subgraph cluster_compilers {
+      label="Compilers";
+      grace [label="Grace", name="Grace Hopper", department="Engineering", team="Compilers"];
+    }
+  }
+  "ada" [label="Ada \"Countess\" Lovelace"];
+  "jane" -> "ada" [style=dashed];
+}
+`
+	c, err := ReadDOT(strings.NewReader(dot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Member{
+		{ID: "jane", Name: "Jane Doe", Department: "Engineering"},
+		{ID: "grace", Name: "Grace Hopper", ManagerID: "jane", Department: "Engineering", Team: "Compilers"},
+		{ID: "ada", Name: `Ada "Countess" Lovelace`, ManagerID: "jane"},
+	}
+	sortMembers(want)
+	if got := c.Members(); !reflect.DeepEqual(got, want) {
+		t.Errorf("read\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		read func() (*Chart, error)
+		// err is the error the read must return, checked with errors.Is, unless cycle is set
+		err   error
+		cycle []string
+		msg   string
+	}{
+		{
+			name: "json cycle",
+			read: func() (*Chart, error) {
+				return ReadJSON(strings.NewReader(`{"members": [
+					{"id": "a", "name": "A", "managerId": "c"},
+					{"id": "b", "name": "B", "managerId": "a"},
+					{"id": "c", "name": "C", "managerId": "b"}]}`))
+			},
+			cycle: []string{"a", "c", "b", "a"},
+			msg:   "management cycle: a -> c -> b -> a",
+		},
+		{
+			name: "json member managing themselves",
+			read: func() (*Chart, error) {
+				return ReadJSON(strings.NewReader(`{"members": [{"id": "a", "name": "A", "managerId": "a"}]}`))
+			},
+			cycle: []string{"a", "a"},
+			msg:   "management cycle: a -> a",
+		},
+		{
+			name: "json dangling manager",
+			read: func() (*Chart, error) {
+				return ReadJSON(strings.NewReader(`{"members": [{"id": "a", "name": "A"}, {"id": "b", "name": "B", "managerId": "x"}]}`))
+			},
+			err: ErrNotFound,
+			msg: "member b: manager x: member not found",
+		},
+		{
+			name: "json duplicate id",
+			read: func() (*Chart, error) {
+				return ReadJSON(strings.NewReader(`{"members": [{"id": "a", "name": "A"}, {"id": "a", "name": "B"}]}`))
+			},
+			err: ErrExists,
+			msg: "member a: member already exists",
+		},
+		{
+			name: "dot cycle",
+			read: func() (*Chart, error) {
+				return ReadDOT(strings.NewReader("digraph org {\n  a -> b;\n  b -> c;\n  c -> a;\n}\n"))
+			},
+			cycle: []string{"a", "c", "b", "a"},
+			msg:   "management cycle: a -> c -> b -> a",
+		},
+		{
+			name: "dot two managers",
+			read: func() (*Chart, error) {
+				return ReadDOT(strings.NewReader("digraph org {\n  a -> c;\n  b -> c;\n}\n"))
+			},
+			msg: "member c has two managers, a and b",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := tt.read()
+			if err == nil {
+				t.Fatalf("read a chart of %v, want an error", c.Members())
+			}
+			if tt.cycle != nil {
+				var cycle *CycleError
+				if !errors.As(err, &cycle) || !slices.Equal(cycle.Cycle, tt.cycle) {
+					t.Errorf("got %v, want a *CycleError with cycle %v", err, tt.cycle)
+				}
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+			if err.Error() != tt.msg {
+				t.Errorf("error %q, want %q", err, tt.msg)
+			}
+		})
+	}
+}
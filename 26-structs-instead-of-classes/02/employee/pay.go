@@ -0,0 +1,156 @@
+package employee
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Cents is an amount of money in cents. Like Amount it is a whole number, so pay adds up exactly,
+// and only dividing it, say a yearly salary into months, needs rounding, see MulDiv.
+type Cents int64
+
+// String formats the cents as a decimal number such as 1234.56 or -0.05.
+func (c Cents) String() string {
+	sign := ""
+	if c < 0 {
+		sign = "-"
+		c = -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
+
+// ErrOutOfRange is returned when an amount of money doesn't fit in Cents.
+var ErrOutOfRange = errors.New("amount of money is out of range")
+
+// MulDiv returns c * num / den rounded to the nearest cent, halves away from zero.
+// It is how a share of an amount is worked out without going through float64.
+// c * num is worked out in 128 bits, so it can't overflow, but MulDiv panics if den is 0
+// or if the result doesn't fit in Cents, like integer division by zero does.
+// Amounts which come from outside the program should use CheckedMulDiv instead.
+func (c Cents) MulDiv(num, den int64) Cents {
+	if den == 0 {
+		panic("employee: MulDiv by zero")
+	}
+	q, err := c.CheckedMulDiv(num, den)
+	if err != nil {
+		panic("employee: " + err.Error())
+	}
+	return q
+}
+
+// CheckedMulDiv is MulDiv which returns an error instead of panicking,
+// one wrapping ErrOutOfRange if the result doesn't fit in Cents.
+func (c Cents) CheckedMulDiv(num, den int64) (Cents, error) {
+	if den == 0 {
+		return 0, errors.New("MulDiv by zero")
+	}
+	neg := (c < 0) != (num < 0) != (den < 0)
+	d := abs(den)
+	hi, lo := bits.Mul64(abs(int64(c)), abs(num))
+	// adding half of den before dividing rounds halves up, and away from zero once the sign is put back
+	lo, carry := bits.Add64(lo, d/2, 0)
+	hi += carry
+	if hi >= d {
+		return 0, fmt.Errorf("MulDiv of %s by %d/%d: %w", c, num, den, ErrOutOfRange)
+	}
+	q, _ := bits.Div64(hi, lo, d)
+	if q > math.MaxInt64 {
+		return 0, fmt.Errorf("MulDiv of %s by %d/%d: %w", c, num, den, ErrOutOfRange)
+	}
+	if neg {
+		return -Cents(q), nil
+	}
+	return Cents(q), nil
+}
+
+// CheckedAdd returns c + d, or an error wrapping ErrOutOfRange if the sum doesn't fit in Cents.
+func (c Cents) CheckedAdd(d Cents) (Cents, error) {
+	sum := c + d
+	if d > 0 && sum < c || d < 0 && sum > c {
+		return 0, fmt.Errorf("%s + %s: %w", c, d, ErrOutOfRange)
+	}
+	return sum, nil
+}
+
+// abs returns the size of n, which for math.MinInt64 only fits in a uint64
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(-n)
+	}
+	return uint64(n)
+}
+
+// ParseCents parses a decimal amount of money with at most two decimals, such as "1234.5" or "1234.56".
+func ParseCents(s string) (Cents, error) {
+	s = strings.TrimSpace(s)
+	neg := false
+	if after, ok := strings.CutPrefix(s, "-"); ok {
+		neg, s = true, after
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(frac) > 2 || strings.Trim(whole+frac, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid amount of money %q, want a number with at most two decimals", s)
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount of money %q", s)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	f, _ := strconv.ParseInt(frac, 10, 64)
+	if w > (math.MaxInt64-f)/100 {
+		return 0, fmt.Errorf("amount of money %q is out of range", s)
+	}
+	c := Cents(w*100 + f)
+	if neg {
+		c = -c
+	}
+	return c, nil
+}
+
+// PayBasis is how an employee is paid.
+type PayBasis string
+
+const (
+	// Salaried employees are paid a yearly salary, spread evenly over the pay periods of the year
+	Salaried PayBasis = "salary"
+	// Hourly employees are paid for the hours they work
+	Hourly PayBasis = "hourly"
+)
+
+// Pay is what an employee earns, see the payroll package for how it is turned into pay slips.
+type Pay struct {
+	Basis PayBasis `json:"basis"`
+	// Rate is the yearly salary for Salaried employees and the pay for an hour for Hourly ones
+	Rate Cents `json:"rateCents"`
+}
+
+// WithPay sets what the employee earns.
+func WithPay(p Pay) Option {
+	return func(e *employee) {
+		e.pay = p
+	}
+}
+
+// Pay returns what the employee earns, the zero Pay if it is not known.
+func (e employee) Pay() Pay {
+	return e.pay
+}
+
+// validate adds the problems with p to invalid, an employee doesn't need to have pay yet
+func (p Pay) validate(invalid func(field string, value any, problem string)) {
+	if p == (Pay{}) {
+		return
+	}
+	switch p.Basis {
+	case Salaried, Hourly:
+	default:
+		invalid("pay.basis", fmt.Sprintf("%q", p.Basis), fmt.Sprintf("must be %s or %s", Salaried, Hourly))
+	}
+	if p.Rate < 0 {
+		invalid("pay.rate", p.Rate, "must not be negative")
+	}
+}
@@ -0,0 +1,133 @@
+package employee
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMulDiv(t *testing.T) {
+	for _, tt := range []struct {
+		c        Cents
+		num, den int64
+		want     Cents
+	}{
+		{c: 100, num: 1, den: 3, want: 33},
+		{c: 200, num: 1, den: 3, want: 67},
+		{c: 5, num: 1, den: 2, want: 3},
+		{c: -5, num: 1, den: 2, want: -3},
+		{c: 5, num: -1, den: 2, want: -3},
+		{c: 5, num: 1, den: -2, want: -3},
+		{c: -5, num: -1, den: -2, want: -3},
+		{c: 7, num: 1, den: 4, want: 2},
+		{c: 250000_00, num: 1, den: 12, want: 20833_33},
+		{c: 0, num: 5, den: 7, want: 0},
+		// the product doesn't fit in an int64, but the result does
+		{c: math.MaxInt64, num: 3, den: 3, want: math.MaxInt64},
+		{c: math.MaxInt64 / 2, num: 1 << 40, den: 1 << 41, want: math.MaxInt64/4 + 1},
+		{c: -math.MaxInt64, num: math.MinInt64, den: math.MinInt64, want: -math.MaxInt64},
+	} {
+		if got := tt.c.MulDiv(tt.num, tt.den); got != tt.want {
+			t.Errorf("%d.MulDiv(%d, %d) = %d, want %d", tt.c, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
+
+func TestMulDivPanics(t *testing.T) {
+	for _, tt := range []struct {
+		c        Cents
+		num, den int64
+		panic    string
+	}{
+		{c: 1, num: 1, den: 0, panic: "by zero"},
+		{c: math.MaxInt64, num: 2, den: 1, panic: "out of range"},
+		{c: math.MaxInt64 / 2, num: 3, den: 1, panic: "out of range"},
+		{c: 1 << 40, num: 1 << 40, den: 1, panic: "out of range"},
+	} {
+		func() {
+			defer func() {
+				if v, _ := recover().(string); !strings.Contains(v, tt.panic) {
+					t.Errorf("%d.MulDiv(%d, %d) panicked with %q, want it to say %q", tt.c, tt.num, tt.den, v, tt.panic)
+				}
+			}()
+			tt.c.MulDiv(tt.num, tt.den)
+		}()
+		// CheckedMulDiv returns the problem instead
+		if _, err := tt.c.CheckedMulDiv(tt.num, tt.den); err == nil || !strings.Contains(err.Error(), tt.panic) {
+			t.Errorf("%d.CheckedMulDiv(%d, %d) returned %v, want it to say %q", tt.c, tt.num, tt.den, err, tt.panic)
+		} else if tt.den != 0 && !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("%d.CheckedMulDiv(%d, %d) returned %v, want ErrOutOfRange", tt.c, tt.num, tt.den, err)
+		}
+	}
+}
+
+func TestCheckedAdd(t *testing.T) {
+	for _, tt := range []struct {
+		c, d Cents
+		// want is only checked if ok is set
+		want Cents
+		ok   bool
+	}{
+		{c: 1, d: 2, want: 3, ok: true},
+		{c: 1, d: -2, want: -1, ok: true},
+		{c: math.MaxInt64 - 1, d: 1, want: math.MaxInt64, ok: true},
+		{c: math.MaxInt64, d: 1},
+		{c: -math.MaxInt64, d: -2},
+		{c: 1e17, d: 1e17 * 92},
+	} {
+		got, err := tt.c.CheckedAdd(tt.d)
+		if tt.ok && (err != nil || got != tt.want) {
+			t.Errorf("%d.CheckedAdd(%d) = %d, %v, want %d", tt.c, tt.d, got, err, tt.want)
+		}
+		if !tt.ok && !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("%d.CheckedAdd(%d) = %d, %v, want ErrOutOfRange", tt.c, tt.d, got, err)
+		}
+	}
+}
+
+func TestParseCents(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want Cents
+		// err is part of the error message, empty if parsing succeeds
+		err string
+	}{
+		{in: "1234.56", want: 1234_56},
+		{in: "1234.5", want: 1234_50},
+		{in: "1234", want: 1234_00},
+		{in: " 0.05 ", want: 5},
+		{in: "-0.05", want: -5},
+		{in: "92233720368547758.07", want: math.MaxInt64},
+		{in: "-92233720368547758.07", want: -math.MaxInt64},
+		{in: "92233720368547758.08", err: "out of range"},
+		{in: "92233720368547759", err: "out of range"},
+		{in: "99999999999999999999", err: "invalid"},
+		{in: "1.234", err: "invalid"},
+		{in: ".5", err: "invalid"},
+		{in: "", err: "invalid"},
+		{in: "-", err: "invalid"},
+		{in: "1,5", err: "invalid"},
+		{in: "+1", err: "invalid"},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseCents(tt.in)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("ParseCents(%q) failed: %v", tt.in, err)
+			case tt.err == "" && got != tt.want:
+				t.Errorf("ParseCents(%q) = %d cents, want %d", tt.in, got, tt.want)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("ParseCents(%q) = %s, %v, want an error saying %q", tt.in, got, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestCentsString(t *testing.T) {
+	for c, want := range map[Cents]string{0: "0.00", 5: "0.05", -5: "-0.05", 1234_56: "1234.56", -100: "-1.00"} {
+		if got := c.String(); got != want {
+			t.Errorf("%d cents formatted as %q, want %q", int64(c), got, want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package payroll_test
+
+import (
+	"fmt"
+	"oop/employee"
+	"oop/employee/calendar"
+	"oop/employee/payroll"
+	"os"
+	"time"
+)
+
+func ExamplePayroll_Run() {
+	repo := employee.NewMemoryRepository()
+	leaves := employee.NewLeaves(repo)
+	cal := calendar.New()
+	if err := cal.LoadFile("uk", "../../holidays/uk.csv"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	leaves.UseCalendar(cal)
+
+	// Pay is counted in whole cents. A salary is spread evenly over the months of the year, and a day of unpaid leave
+	// costs the share of the month's salary that one of its working days is.
+	// Hourly employees are paid for the hours they worked, and the hours beyond the working days of the month are overtime.
+	linus, _ := employee.New("Linus", "Torvalds", 25, 0, employee.WithID("linus"), employee.WithRegion("uk"),
+		employee.WithPay(employee.Pay{Basis: employee.Salaried, Rate: 52000_00}))
+	margaret, _ := employee.New("Margaret", "Hamilton", 25, 0, employee.WithID("margaret"), employee.WithRegion("uk"),
+		employee.WithPay(employee.Pay{Basis: employee.Hourly, Rate: 31_50}))
+	repo.Create(linus)
+	repo.Create(margaret)
+	r, _ := leaves.Submit("linus", employee.Unpaid, date("2025-02-06"), date("2025-02-07"), "moving house")
+	leaves.Approve(r.ID, "jane")
+
+	run := payroll.New(repo, leaves, payroll.Rules{OvertimePercent: 150})
+	slips, err := run.Run(payroll.Month(2025, time.February), payroll.Timesheet{"margaret": employee.Hours(164)})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	payroll.Render(os.Stdout, employee.Text, slips...)
+	// Output:
+	// Pay slip for Margaret Hamilton, 2025-02-01 to 2025-02-28, 20 working days
+	// Hours      160h at 31.50  5040.00
+	// Overtime   4h at 150%      189.00
+	// Gross pay                 5229.00
+	//
+	// Pay slip for Linus Torvalds, 2025-02-01 to 2025-02-28, 20 working days
+	// Salary        1/12 of 52000.00      4333.33
+	// Unpaid leave  2 of 20 working days  -433.33
+	// Gross pay                           3900.00
+}
@@ -0,0 +1,213 @@
+// Package payroll works out what employees are paid for a pay period, from the Pay on their record,
+// the hours they worked and the unpaid leave they took.
+//
+// All money is in whole cents, see employee.Cents, and is only rounded where it has to be divided,
+// so the lines of a pay slip always add up to its total.
+package payroll
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"oop/employee"
+	"strings"
+	"time"
+)
+
+// A Period is what employees are paid for at once, such as a month.
+type Period struct {
+	// From and To are the first and last day of the period
+	From time.Time
+	To   time.Time
+	// PerYear is the number of periods in a year, which a yearly salary is divided by
+	PerYear int
+}
+
+// Month returns the period of a calendar month, of which there are 12 in a year.
+func Month(year int, month time.Month) Period {
+	from := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return Period{From: from, To: from.AddDate(0, 1, -1), PerYear: 12}
+}
+
+// Week returns the period of the 7 days starting with start, of which there are 52 in a year.
+func Week(start time.Time) Period {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	return Period{From: start, To: start.AddDate(0, 0, 6), PerYear: 52}
+}
+
+func (p Period) String() string {
+	return p.From.Format(time.DateOnly) + " to " + p.To.Format(time.DateOnly)
+}
+
+// Rules are the overtime rules of a payroll.
+type Rules struct {
+	// OvertimeAfter is the time worked in a period after which the rest is overtime.
+	// Zero means the working hours of the period, which are 8 hours for every working day in it.
+	OvertimeAfter employee.Amount
+	// OvertimePercent is what an hour of overtime is paid as a percentage of a normal hour, such as 150 for time and a half.
+	// Zero means overtime is paid like any other hour.
+	OvertimePercent int64
+	// SalariedOvertime pays salaried employees for overtime too, at the hourly rate their salary works out to.
+	// Without it their salary covers any hours they work.
+	SalariedOvertime bool
+}
+
+// A Timesheet is the time every employee worked in a period, by employee id.
+// Time is counted like leave, in whole quarter hours, so employee.Hours(38) is 38 hours.
+type Timesheet map[string]employee.Amount
+
+// ErrNoPay is returned for employees whose pay is not known, see employee.WithPay.
+var ErrNoPay = errors.New("employee has no pay")
+
+// A Payroll works out the pay of the employees in a Repository.
+type Payroll struct {
+	repo   employee.Repository
+	leaves *employee.Leaves
+	rules  Rules
+}
+
+// New returns a payroll for the employees in repo.
+// leaves says which days are working days and how much unpaid leave has been taken.
+func New(repo employee.Repository, leaves *employee.Leaves, rules Rules) *Payroll {
+	return &Payroll{repo: repo, leaves: leaves, rules: rules}
+}
+
+// Run returns a pay slip for every employee whose pay is known, sorted like Repository.List sorts them.
+// Employees who are missing from hours worked no hours, which only matters for hourly employees and overtime.
+// Pay too large for employee.Cents fails the whole run with an error wrapping employee.ErrOutOfRange.
+func (p *Payroll) Run(period Period, hours Timesheet) ([]Slip, error) {
+	if err := period.check(); err != nil {
+		return nil, err
+	}
+	es, err := p.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	var slips []Slip
+	for _, e := range es {
+		if e.Pay() == (employee.Pay{}) {
+			continue
+		}
+		s, err := p.slip(e.Record(), period, hours[e.ID()])
+		if err != nil {
+			return nil, err
+		}
+		slips = append(slips, s)
+	}
+	return slips, nil
+}
+
+// Slip returns the pay slip of one employee who worked the given time in period.
+func (p *Payroll) Slip(employeeID string, period Period, worked employee.Amount) (Slip, error) {
+	if err := period.check(); err != nil {
+		return Slip{}, err
+	}
+	e, err := p.repo.Get(employeeID)
+	if err != nil {
+		return Slip{}, err
+	}
+	if e.Pay() == (employee.Pay{}) {
+		return Slip{}, fmt.Errorf("employee %s: %w", employeeID, ErrNoPay)
+	}
+	return p.slip(e.Record(), period, worked)
+}
+
+func (p Period) check() error {
+	if p.To.Before(p.From) {
+		return fmt.Errorf("pay period ends on %s, before it starts on %s", p.To.Format(time.DateOnly), p.From.Format(time.DateOnly))
+	}
+	if p.PerYear <= 0 {
+		return fmt.Errorf("pay period has %d periods a year, it needs at least 1", p.PerYear)
+	}
+	return nil
+}
+
+// slip works out the pay of r, whose Pay is set.
+// The rate and the time worked come from outside the program, so every amount is checked,
+// and pay which doesn't fit in employee.Cents is an error wrapping employee.ErrOutOfRange.
+func (p *Payroll) slip(r employee.Record, period Period, worked employee.Amount) (Slip, error) {
+	s := Slip{
+		EmployeeID:  r.ID,
+		Name:        r.FirstName + " " + r.LastName,
+		From:        period.From.Format(time.DateOnly),
+		To:          period.To.Format(time.DateOnly),
+		Basis:       r.Pay.Basis,
+		WorkingDays: p.leaves.WorkingDays(r.Region, period.From, period.To),
+	}
+	// normal is the time the employee is expected to work in the period
+	normal := employee.Days(s.WorkingDays)
+	overtimeAfter := p.rules.OvertimeAfter
+	if overtimeAfter == 0 {
+		overtimeAfter = normal
+	}
+	overtime := max(worked-overtimeAfter, 0)
+	percent := p.rules.OvertimePercent
+	if percent == 0 {
+		percent = 100
+	}
+	// line adds a line of c * num / den to the slip
+	line := func(description, quantity string, c employee.Cents, num, den int64) error {
+		amount, err := c.CheckedMulDiv(num, den)
+		if err == nil {
+			err = s.add(description, quantity, amount)
+		}
+		if err != nil {
+			return fmt.Errorf("employee %s: %s: %w", r.ID, strings.ToLower(description), err)
+		}
+		return nil
+	}
+	// overtime is paid at percent of a normal hour, and even the time and the percentage multiplied may not fit
+	overtimePercent, ok := mul(int64(overtime), percent)
+	if !ok {
+		return Slip{}, fmt.Errorf("employee %s: overtime: %s days at %d%%: %w", r.ID, overtime, percent, employee.ErrOutOfRange)
+	}
+
+	var err error
+	switch r.Pay.Basis {
+	case employee.Salaried:
+		// dividing can't go out of range
+		base := r.Pay.Rate.MulDiv(1, int64(period.PerYear))
+		if err = line("Salary", fmt.Sprintf("1/%d of %s", period.PerYear, r.Pay.Rate), base, 1, 1); err != nil || normal == 0 {
+			break
+		}
+		// a day of unpaid leave costs the share of the period's salary that a working day is
+		// Usage doesn't include its last day, so it runs to the day after the period
+		unpaid := p.leaves.Taken(r.ID, employee.Unpaid)(period.From, period.To.AddDate(0, 0, 1))
+		if unpaid > 0 {
+			// leave taken on days which are not working days can add up to more than the period, but never to less than no pay,
+			// and a share of base which is out of range is more than base
+			deduction, rangeErr := base.CheckedMulDiv(int64(unpaid), int64(normal))
+			if rangeErr != nil {
+				deduction = base
+			}
+			if err = line("Unpaid leave", fmt.Sprintf("%s of %d working days", unpaid, s.WorkingDays), -min(deduction, base), 1, 1); err != nil {
+				break
+			}
+		}
+		if overtime > 0 && p.rules.SalariedOvertime {
+			err = line("Overtime", fmt.Sprintf("%gh at %d%%", overtime.Hours(), percent), base, overtimePercent, int64(normal)*100)
+		}
+	case employee.Hourly:
+		// Rate is for an hour, and time is counted in quarter hours
+		regular := worked - overtime
+		if err = line("Hours", fmt.Sprintf("%gh at %s", regular.Hours(), r.Pay.Rate), r.Pay.Rate, int64(regular), int64(employee.Hour)); err != nil {
+			break
+		}
+		if overtime > 0 {
+			err = line("Overtime", fmt.Sprintf("%gh at %d%%", overtime.Hours(), percent), r.Pay.Rate, overtimePercent, int64(employee.Hour)*100)
+		}
+	}
+	if err != nil {
+		return Slip{}, err
+	}
+	return s, nil
+}
+
+// mul returns a * b, and whether it fits in an int64
+func mul(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	p := a * b
+	return p, p/b == a && !(a == math.MinInt64 && b == -1)
+}
@@ -0,0 +1,217 @@
+package payroll_test
+
+import (
+	"errors"
+	"oop/employee"
+	"oop/employee/calendar"
+	"oop/employee/payroll"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// setup stores employees with the given records and returns a payroll which pays them with rules,
+// counting Monday to Friday as working days
+func setup(t *testing.T, rules payroll.Rules, records ...employee.Record) (*payroll.Payroll, *employee.Leaves) {
+	t.Helper()
+	repo := employee.NewMemoryRepository()
+	for _, r := range records {
+		e, err := r.Employee()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Create(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	leaves := employee.NewLeaves(repo)
+	leaves.UseCalendar(calendar.New())
+	return payroll.New(repo, leaves, rules), leaves
+}
+
+func salaried(id string, yearly employee.Cents, days ...employee.LeaveDay) employee.Record {
+	return employee.Record{ID: id, FirstName: "Sam", LastName: id, Pay: &employee.Pay{Basis: employee.Salaried, Rate: yearly}, LeaveDays: days}
+}
+
+func hourly(id string, rate employee.Cents) employee.Record {
+	return employee.Record{ID: id, FirstName: "Sam", LastName: id, Pay: &employee.Pay{Basis: employee.Hourly, Rate: rate}}
+}
+
+// unpaid returns a day of unpaid leave on every date from from to to
+func unpaid(from, to string) []employee.LeaveDay {
+	var days []employee.LeaveDay
+	for d := date(from); !d.After(date(to)); d = d.AddDate(0, 0, 1) {
+		days = append(days, employee.LeaveDay{Type: employee.Unpaid, Day: d, Amount: employee.Day})
+	}
+	return days
+}
+
+// wantLines checks the amounts of the slip's lines by description, and that they add up to its gross pay
+func wantLines(t *testing.T, s payroll.Slip, lines map[string]employee.Cents) {
+	t.Helper()
+	var sum employee.Cents
+	got := make(map[string]employee.Cents)
+	for _, l := range s.Lines {
+		got[l.Description] = l.Amount
+		sum += l.Amount
+	}
+	if len(got) != len(lines) {
+		t.Errorf("slip has lines %+v, want %v", s.Lines, lines)
+	}
+	for d, want := range lines {
+		if a, ok := got[d]; !ok || a != want {
+			t.Errorf("%s is %s, found %t, want %s", d, a, ok, want)
+		}
+	}
+	if sum != s.Gross {
+		t.Errorf("lines add up to %s, but gross pay is %s", sum, s.Gross)
+	}
+}
+
+// July 2024 has 23 working days, which are 184 hours
+var july = payroll.Month(2024, time.July)
+
+func TestSalary(t *testing.T) {
+	p, _ := setup(t, payroll.Rules{SalariedOvertime: true, OvertimePercent: 150},
+		salaried("a", 120000_00),
+		salaried("b", 120000_00, unpaid("2024-07-02", "2024-07-03")...),
+		// leave outside the period doesn't count
+		salaried("c", 120000_00, unpaid("2024-06-28", "2024-06-30")...),
+		// nor does more leave than there are working days make the pay negative
+		salaried("d", 120000_00, unpaid("2024-07-01", "2024-07-31")...),
+	)
+	for _, tt := range []struct {
+		id     string
+		worked employee.Amount
+		lines  map[string]employee.Cents
+	}{
+		{"a", employee.Hours(184), map[string]employee.Cents{"Salary": 10000_00}},
+		// 10000.00 * 2/23 is 869.565...
+		{"b", employee.Hours(168), map[string]employee.Cents{"Salary": 10000_00, "Unpaid leave": -869_57}},
+		{"c", employee.Hours(184), map[string]employee.Cents{"Salary": 10000_00}},
+		{"d", 0, map[string]employee.Cents{"Salary": 10000_00, "Unpaid leave": -10000_00}},
+		// 10 hours at 150% of 10000.00 / 184 hours is 815.217...
+		{"a", employee.Hours(194), map[string]employee.Cents{"Salary": 10000_00, "Overtime": 815_22}},
+	} {
+		s, err := p.Slip(tt.id, july, tt.worked)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.WorkingDays != 23 {
+			t.Errorf("%d working days in July, want 23", s.WorkingDays)
+		}
+		wantLines(t, s, tt.lines)
+	}
+}
+
+func TestSalariedOvertimeIsOptional(t *testing.T) {
+	p, _ := setup(t, payroll.Rules{}, salaried("a", 120000_00))
+	s, err := p.Slip("a", july, employee.Hours(200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLines(t, s, map[string]employee.Cents{"Salary": 10000_00})
+}
+
+func TestHourly(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		rules  payroll.Rules
+		rate   employee.Cents
+		worked employee.Amount
+		lines  map[string]employee.Cents
+	}{
+		{"normal hours", payroll.Rules{}, 25_00, employee.Hours(170), map[string]employee.Cents{"Hours": 4250_00}},
+		{"overtime after the working hours", payroll.Rules{OvertimePercent: 150}, 25_00, employee.Hours(190),
+			map[string]employee.Cents{"Hours": 4600_00, "Overtime": 225_00}},
+		{"overtime after a set time", payroll.Rules{OvertimeAfter: employee.Hours(160), OvertimePercent: 150}, 25_00, employee.Hours(170),
+			map[string]employee.Cents{"Hours": 4000_00, "Overtime": 375_00}},
+		{"overtime at the normal rate", payroll.Rules{OvertimeAfter: employee.Hours(160)}, 25_00, employee.Hours(170),
+			map[string]employee.Cents{"Hours": 4000_00, "Overtime": 250_00}},
+		// a quarter of an hour at 150% of 25.01 is 9.37875
+		{"rounding", payroll.Rules{OvertimeAfter: employee.Hours(1), OvertimePercent: 150}, 25_01, employee.Hours(1) + employee.QuarterHour,
+			map[string]employee.Cents{"Hours": 25_01, "Overtime": 9_38}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := setup(t, tt.rules, hourly("a", tt.rate))
+			s, err := p.Slip("a", july, tt.worked)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantLines(t, s, tt.lines)
+		})
+	}
+}
+
+func TestUnpaidLeaveTakenWithoutARequest(t *testing.T) {
+	p, leaves := setup(t, payroll.Rules{}, salaried("a", 120000_00))
+	// Take records the leave as taken today, like the take-leave command
+	if _, err := leaves.Take("a", employee.Unpaid, employee.Days(1)); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	s, err := p.Slip("a", payroll.Month(now.Year(), now.Month()), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := employee.Cents(10000_00)
+	wantLines(t, s, map[string]employee.Cents{"Salary": base, "Unpaid leave": -base.MulDiv(1, int64(s.WorkingDays))})
+}
+
+func TestRun(t *testing.T) {
+	p, _ := setup(t, payroll.Rules{}, salaried("b", 120000_00), hourly("a", 25_00),
+		employee.Record{ID: "c", FirstName: "Sam", LastName: "c"})
+	slips, err := p.Run(july, payroll.Timesheet{"a": employee.Hours(10)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the employee without pay has no slip
+	if len(slips) != 2 || slips[0].EmployeeID != "a" || slips[1].EmployeeID != "b" {
+		t.Fatalf("got slips %+v, want one for a and b", slips)
+	}
+	if slips[0].Gross != 250_00 || slips[1].Gross != 10000_00 {
+		t.Errorf("gross pay is %s and %s, want 250.00 and 10000.00", slips[0].Gross, slips[1].Gross)
+	}
+	if _, err := p.Run(payroll.Period{From: july.To, To: july.From, PerYear: 12}, nil); err == nil {
+		t.Error("a period which ends before it starts was paid")
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		rules  payroll.Rules
+		record employee.Record
+		worked employee.Amount
+		msg    string
+	}{
+		{"hours", payroll.Rules{}, hourly("a", 1e17), employee.Hours(160),
+			"employee a: hours: MulDiv of 1000000000000000.00 by 640/4: amount of money is out of range"},
+		// 184 hours and 200 hours of overtime at 150% each fit, but not together
+		{"gross pay", payroll.Rules{OvertimePercent: 150}, hourly("a", 2e16), employee.Hours(384),
+			"employee a: overtime: gross pay: 36800000000000000.00 + 60000000000000000.00: amount of money is out of range"},
+		{"overtime", payroll.Rules{OvertimePercent: 150}, hourly("a", 25_00), employee.Days(1e16),
+			"employee a: overtime: 9999999999999977 days at 150%: amount of money is out of range"},
+		{"salaried overtime", payroll.Rules{OvertimePercent: 150, SalariedOvertime: true}, salaried("a", 120000_00), employee.Days(1e16),
+			"employee a: overtime: 9999999999999977 days at 150%: amount of money is out of range"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := setup(t, tt.rules, tt.record)
+			_, err := p.Slip("a", july, tt.worked)
+			if !errors.Is(err, employee.ErrOutOfRange) || err.Error() != tt.msg {
+				t.Errorf("Slip returned %v, want %q", err, tt.msg)
+			}
+			// Run fails the same way instead of panicking
+			if slips, err := p.Run(july, payroll.Timesheet{"a": tt.worked}); !errors.Is(err, employee.ErrOutOfRange) {
+				t.Errorf("Run returned %v and %d slips, want ErrOutOfRange", err, len(slips))
+			}
+		})
+	}
+}
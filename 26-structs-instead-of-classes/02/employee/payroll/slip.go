@@ -0,0 +1,87 @@
+package payroll
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"oop/employee"
+	"text/tabwriter"
+)
+
+// A Slip is the pay of one employee for one period, line by line.
+type Slip struct {
+	EmployeeID string `json:"employeeId"`
+	Name       string `json:"name"`
+	// From and To are the first and last day of the period, formatted as 2006-01-02
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	Basis       employee.PayBasis `json:"basis"`
+	WorkingDays int               `json:"workingDays"`
+	Lines       []Line            `json:"lines"`
+	// Gross is the sum of the lines, before tax
+	Gross employee.Cents `json:"grossCents"`
+}
+
+// A Line is one amount paid, or deducted if it is negative.
+type Line struct {
+	Description string `json:"description"`
+	// Quantity says how the amount was worked out, such as "160h at 25.00"
+	Quantity string         `json:"quantity,omitempty"`
+	Amount   employee.Cents `json:"amountCents"`
+}
+
+// add adds a line to the slip and its amount to the gross pay, unless the gross pay would be out of range
+func (s *Slip) add(description, quantity string, amount employee.Cents) error {
+	gross, err := s.Gross.CheckedAdd(amount)
+	if err != nil {
+		return fmt.Errorf("gross pay: %w", err)
+	}
+	s.Lines = append(s.Lines, Line{Description: description, Quantity: quantity, Amount: amount})
+	s.Gross = gross
+	return nil
+}
+
+// Render writes the slips to w as text for people, or as a JSON array for programs.
+// Other formats are an error.
+func Render(w io.Writer, f employee.Format, slips ...Slip) error {
+	switch f {
+	case employee.Text:
+		for i, s := range slips {
+			if i > 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			if err := s.writeText(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	case employee.JSON:
+		if slips == nil {
+			// an empty array rather than null
+			slips = []Slip{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(slips)
+	}
+	return fmt.Errorf("pay slips can't be rendered as %q, only as text or json", f)
+}
+
+// writeText writes the slip with its amounts lined up on the right
+func (s Slip) writeText(w io.Writer) error {
+	amounts := make([]string, len(s.Lines))
+	width := len(s.Gross.String())
+	for i, l := range s.Lines {
+		amounts[i] = l.Amount.String()
+		width = max(width, len(amounts[i]))
+	}
+	fmt.Fprintf(w, "Pay slip for %s, %s to %s, %d working days\n", s.Name, s.From, s.To, s.WorkingDays)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, l := range s.Lines {
+		fmt.Fprintf(tw, "%s\t%s\t%*s\n", l.Description, l.Quantity, width, amounts[i])
+	}
+	fmt.Fprintf(tw, "Gross pay\t\t%*s\n", width, s.Gross)
+	return tw.Flush()
+}
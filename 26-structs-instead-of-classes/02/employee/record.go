@@ -0,0 +1,102 @@
+package employee
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// A Record is an employee as plain data, which is how it is written to JSON.
+// The fields of employee are unexported, so encoding/json can't see them; Record copies them into exported fields.
+type Record struct {
+	// ID and Version are set by New and a Repository, they can be left out when creating an employee
+	ID        string `json:"id,omitempty"`
+	Version   int    `json:"version,omitempty"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	// TotalLeaves and LeavesTaken are the annual leave, as numbers of days such as 2.5
+	TotalLeaves Amount `json:"totalLeaves"`
+	LeavesTaken Amount `json:"leavesTaken"`
+	// Leave holds the other leave types the employee has or has taken
+	Leave map[LeaveType]Allowance `json:"leave,omitempty"`
+	// StartDate is formatted as 2006-01-02, empty if it is not known
+	StartDate string `json:"startDate,omitempty"`
+	Region    string `json:"region,omitempty"`
+	// Pay is nil if the employee's pay is not known
+	Pay *Pay `json:"pay,omitempty"`
+	// LeaveDays is the leave taken day by day, see LeaveDay
+	LeaveDays []LeaveDay `json:"leaveDays,omitempty"`
+}
+
+// Record returns the employee as a Record.
+func (e employee) Record() Record {
+	r := Record{
+		ID:          e.id,
+		Version:     e.version,
+		FirstName:   e.firstName,
+		LastName:    e.lastName,
+		TotalLeaves: e.Allowance(Annual).Total,
+		LeavesTaken: e.Allowance(Annual).Taken,
+		Region:      e.region,
+	}
+	for i, t := range leaveTypes {
+		if a := e.leave[i]; t != Annual && a != (Allowance{}) {
+			if r.Leave == nil {
+				r.Leave = make(map[LeaveType]Allowance)
+			}
+			r.Leave[t] = a
+		}
+	}
+	if !e.startDate.IsZero() {
+		r.StartDate = e.startDate.Format(time.DateOnly)
+	}
+	if e.pay != (Pay{}) {
+		pay := e.pay
+		r.Pay = &pay
+	}
+	if len(e.leaveDays) > 0 {
+		r.LeaveDays = append([]LeaveDay(nil), e.leaveDays...)
+	}
+	return r
+}
+
+// Employee returns the employee the record describes, or a *ValidationError like New if it isn't valid.
+// A record without an id gets a new one.
+func (r Record) Employee() (Employee, error) {
+	e := employee{
+		id:        r.ID,
+		version:   r.Version,
+		firstName: r.FirstName,
+		lastName:  r.LastName,
+		region:    r.Region,
+		leaveDays: append([]LeaveDay(nil), r.LeaveDays...),
+	}
+	*e.allowance(Annual) = Allowance{Total: r.TotalLeaves, Taken: r.LeavesTaken}
+	for t, a := range r.Leave {
+		if err := checkType(t); err != nil {
+			return nil, &ValidationError{Fields: []FieldError{{Field: "leave", Value: t, Problem: "is not a leave type"}}}
+		}
+		*e.allowance(t) = a
+	}
+	if r.StartDate != "" {
+		t, err := time.Parse(time.DateOnly, r.StartDate)
+		if err != nil {
+			return nil, &ValidationError{Fields: []FieldError{{Field: "startDate", Value: r.StartDate, Problem: "must be a date such as 2006-01-02"}}}
+		}
+		e.startDate = t
+	}
+	if r.Pay != nil {
+		e.pay = *r.Pay
+	}
+	if e.id == "" {
+		e.id = newID()
+	}
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// MarshalJSON writes the employee as its Record.
+func (e employee) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Record())
+}
@@ -0,0 +1,170 @@
+package employee
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// A Repository stores employees so they outlive the program that created them.
+//
+// Every stored employee has a version. Update and Delete take the employee (or version) the caller last read,
+// and fail with a *ConflictError if someone else has changed it since.
+// This is optimistic concurrency: instead of locking the employee while it is being edited,
+// we check at the end that nobody else got there first.
+type Repository interface {
+	// Create stores a new employee and returns it with version 1.
+	// Like New and Update, it returns a *ValidationError if the employee isn't valid.
+	Create(e Employee) (Employee, error)
+	// Get returns the employee with the given id, or ErrNotFound.
+	Get(id string) (Employee, error)
+	// Update replaces the stored employee with e if e's version is the stored one, and returns it with the next version.
+	Update(e Employee) (Employee, error)
+	// Delete removes the employee with the given id if version is the stored one.
+	Delete(id string, version int) error
+	// List returns every stored employee, sorted by last name, first name and id.
+	List() ([]Employee, error)
+}
+
+var (
+	// ErrNotFound is returned when there is no employee with the requested id.
+	ErrNotFound = errors.New("employee not found")
+	// ErrExists is returned by Create when an employee with the same id is already stored.
+	ErrExists = errors.New("employee already exists")
+)
+
+// A ConflictError is returned when an update is based on an out of date version of the employee.
+// The caller should Get the employee again, reapply its change and retry.
+type ConflictError struct {
+	ID string
+	// Version is the version the caller had
+	Version int
+	// Current is the version that is stored
+	Current int
+}
+
+func (c *ConflictError) Error() string {
+	return fmt.Sprintf("employee %s was changed by someone else: have version %d, stored version is %d", c.ID, c.Version, c.Current)
+}
+
+// memoryRepository keeps employees in a map guarded by a mutex, see 25-mutex.
+type memoryRepository struct {
+	mu        sync.RWMutex
+	employees map[string]employee
+}
+
+// NewMemoryRepository returns a Repository that keeps employees in memory.
+// It is safe to use from several Goroutines.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{employees: make(map[string]employee)}
+}
+
+func (r *memoryRepository) Create(e Employee) (Employee, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return stored(create(r.employees, e.value()))
+}
+
+func (r *memoryRepository) Get(id string) (Employee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.employees[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+func (r *memoryRepository) Update(e Employee) (Employee, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return stored(update(r.employees, e.value()))
+}
+
+func (r *memoryRepository) Delete(id string, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return remove(r.employees, id, version)
+}
+
+func (r *memoryRepository) List() ([]Employee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return list(r.employees), nil
+}
+
+// stored returns the result of create or update as an Employee, which is nil if there is an error
+func stored(e employee, err error) (Employee, error) {
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// create, update, remove and list are the rules shared by every Repository,
+// applied to a map of the stored employees which the caller has locked
+
+func create(employees map[string]employee, e employee) (employee, error) {
+	if e.id == "" {
+		e.id = newID()
+	}
+	if err := e.validate(); err != nil {
+		return employee{}, err
+	}
+	if _, ok := employees[e.id]; ok {
+		return employee{}, ErrExists
+	}
+	// the changes are saved now, whoever reads the employee next starts without them
+	e.changes = nil
+	e.version = 1
+	employees[e.id] = e
+	return e, nil
+}
+
+func update(employees map[string]employee, e employee) (employee, error) {
+	stored, ok := employees[e.id]
+	if !ok {
+		return employee{}, ErrNotFound
+	}
+	if stored.version != e.version {
+		return employee{}, &ConflictError{ID: e.id, Version: e.version, Current: stored.version}
+	}
+	if err := e.validate(); err != nil {
+		return employee{}, err
+	}
+	e.changes = nil
+	e.version++
+	employees[e.id] = e
+	return e, nil
+}
+
+func remove(employees map[string]employee, id string, version int) error {
+	stored, ok := employees[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.version != version {
+		return &ConflictError{ID: id, Version: version, Current: stored.version}
+	}
+	delete(employees, id)
+	return nil
+}
+
+func list(employees map[string]employee) []Employee {
+	l := make([]Employee, 0, len(employees))
+	for _, e := range employees {
+		l = append(l, e)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		a, b := l[i].value(), l[j].value()
+		if a.lastName != b.lastName {
+			return a.lastName < b.lastName
+		}
+		if a.firstName != b.firstName {
+			return a.firstName < b.firstName
+		}
+		return a.id < b.id
+	})
+	return l
+}
@@ -0,0 +1,126 @@
+package employee
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// An Option sets an optional field of an employee created by New.
+// Options are functions so New can take any number of them, and new ones can be added without changing New's signature.
+type Option func(e *employee)
+
+// WithID gives the employee an existing id instead of a new random one,
+// for employees which were created somewhere else and are being imported.
+func WithID(id string) Option {
+	return func(e *employee) {
+		e.id = id
+	}
+}
+
+// WithLeave sets the annual leave the employee has and has taken, replacing the whole days given to New.
+func WithLeave(total, taken Amount) Option {
+	return WithAllowance(Annual, total, taken)
+}
+
+// WithStartDate sets the day the employee joined.
+func WithStartDate(t time.Time) Option {
+	return func(e *employee) {
+		e.startDate = day(t)
+	}
+}
+
+// WithRegion sets the region the employee works in, which decides their public holidays.
+func WithRegion(region string) Option {
+	return func(e *employee) {
+		e.region = region
+	}
+}
+
+// A FieldError describes one field of an employee that is not valid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Value   any    `json:"value"`
+	Problem string `json:"problem"`
+}
+
+func (f FieldError) Error() string {
+	return fmt.Sprintf("%s %v: %s", f.Field, f.Value, f.Problem)
+}
+
+// A ValidationError is returned by New when it is given values which don't make a usable employee.
+// It lists every problem, not only the first, so they can all be fixed at once.
+//
+//	var invalid *employee.ValidationError
+//	if errors.As(err, &invalid) {
+//		for _, f := range invalid.Fields { ... }
+//	}
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (v *ValidationError) Error() string {
+	problems := make([]string, len(v.Fields))
+	for i, f := range v.Fields {
+		problems[i] = f.Error()
+	}
+	return "invalid employee: " + strings.Join(problems, "; ")
+}
+
+// Has reports whether field is one of the invalid fields.
+func (v *ValidationError) Has(field string) bool {
+	for _, f := range v.Fields {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// validate returns a *ValidationError listing everything wrong with e, or nil if e is usable
+func (e employee) validate() error {
+	fields := append([]FieldError(nil), e.problems...)
+	invalid := func(field string, value any, problem string) {
+		fields = append(fields, FieldError{Field: field, Value: value, Problem: problem})
+	}
+	if strings.TrimSpace(e.firstName) == "" {
+		invalid("firstName", fmt.Sprintf("%q", e.firstName), "must not be empty")
+	}
+	if strings.TrimSpace(e.lastName) == "" {
+		invalid("lastName", fmt.Sprintf("%q", e.lastName), "must not be empty")
+	}
+	for i, t := range leaveTypes {
+		// annual leave keeps the names New gives its parameters
+		total, taken := string(t)+".total", string(t)+".taken"
+		if t == Annual {
+			total, taken = "totalLeaves", "leavesTaken"
+		}
+		a := e.leave[i]
+		if a.Total < 0 {
+			invalid(total, a.Total, "must not be negative")
+		}
+		if a.Taken < 0 {
+			invalid(taken, a.Taken, "must not be negative")
+		} else if a.Taken > a.Total && a.Total >= 0 && !RulesFor(t).Unlimited {
+			invalid(taken, a.Taken, fmt.Sprintf("must not be more than %s %s", total, a.Total))
+		}
+	}
+	e.pay.validate(invalid)
+	for _, d := range e.leaveDays {
+		switch {
+		case checkType(d.Type) != nil:
+			invalid("leaveDays", d.Type, "is not a leave type")
+		case d.Day.IsZero():
+			invalid("leaveDays", d.Day, "must have a day")
+		case d.Amount <= 0:
+			invalid("leaveDays", d.Amount, "must be more than 0")
+		}
+	}
+	if strings.TrimSpace(e.id) == "" {
+		invalid("id", fmt.Sprintf("%q", e.id), "must not be empty")
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package main
 
-import "oop/employee"
+import (
+	"fmt"
+	"oop/employee"
+)
 
 // The program we wrote above looks alright but there is a subtle issue in it.
 // Let’s see what happens when we define the employee struct with zero values.
@@ -19,10 +22,22 @@ import "oop/employee"
 
 func main() {
 	// We have created a new employee by passing the required parameters to the New function.
-	e := employee.New("Sam", "Adolf", 30, 20)
+	// New checks them first, and returns an error instead of an employee that isn't usable.
+	e, err := employee.New("Sam", "Adolf", 30, 20)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	e.LeavesRemaining()
+
+	_, err = employee.New("", "Adolf", 30, 20)
+	fmt.Println(err)
 }
 
 // Sam Adolf has 10 leaves remaining
+// invalid employee: firstName "": must not be empty
+
+// The example_test.go files in the employee package and the packages under it show what else employees can do,
+// run them with go test ./...
 
 // Although Go doesn’t support classes, structs can effectively be used instead of classes and methods of signature New(parameters) can be used in the place of constructors